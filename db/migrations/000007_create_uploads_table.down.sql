@@ -0,0 +1 @@
+DROP TABLE uploads;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE uploads (
+	id serial4 NOT NULL,
+	"path" text NOT NULL,
+	hash varchar(64) NULL,
+	"size" int8 DEFAULT 0 NOT NULL,
+	ref_count int4 DEFAULT 0 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	last_seen_at timestamp DEFAULT now() NOT NULL,
+	CONSTRAINT uploads_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_upload_path UNIQUE ("path"),
+	CONSTRAINT uploads_ref_count_check CHECK ((ref_count >= 0))
+);
+
+CREATE INDEX idx_uploads_orphans ON uploads (last_seen_at) WHERE ref_count = 0;
+
+-- Files uploaded before content addressing are tracked by path only
+INSERT INTO uploads ("path", ref_count)
+SELECT image_path, COUNT(*)
+FROM posts
+WHERE image_path IS NOT NULL
+GROUP BY image_path;
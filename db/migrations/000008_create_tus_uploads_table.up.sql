@@ -0,0 +1,16 @@
+CREATE TABLE tus_uploads (
+	id varchar(64) NOT NULL,
+	user_id int4 NOT NULL,
+	upload_length int8 NOT NULL,
+	upload_offset int8 DEFAULT 0 NOT NULL,
+	metadata text NULL,
+	file_path text NULL,
+	created_at timestamp DEFAULT now() NULL,
+	expires_at timestamp NOT NULL,
+	completed_at timestamp NULL,
+	CONSTRAINT tus_uploads_pkey PRIMARY KEY (id),
+	CONSTRAINT tus_uploads_offset_check CHECK ((upload_offset >= 0 AND upload_offset <= upload_length)),
+	CONSTRAINT fk_tus_uploads_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_tus_uploads_expires_at ON tus_uploads (expires_at);
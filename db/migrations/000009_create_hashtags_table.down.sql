@@ -0,0 +1,3 @@
+DROP TABLE hashtag_follows;
+DROP TABLE post_hashtags;
+DROP TABLE hashtags;
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE hashtags (
+	id serial4 NOT NULL,
+	"name" varchar(100) NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT hashtags_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_hashtag_name UNIQUE ("name")
+);
+
+CREATE TABLE post_hashtags (
+	post_id int4 NOT NULL,
+	hashtag_id int4 NOT NULL,
+	CONSTRAINT post_hashtags_pkey PRIMARY KEY (post_id, hashtag_id),
+	CONSTRAINT fk_post_hashtags_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	CONSTRAINT fk_post_hashtags_hashtag FOREIGN KEY (hashtag_id) REFERENCES hashtags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_post_hashtags_hashtag ON post_hashtags (hashtag_id, post_id);
+
+CREATE TABLE hashtag_follows (
+	user_id int4 NOT NULL,
+	hashtag_id int4 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT hashtag_follows_pkey PRIMARY KEY (user_id, hashtag_id),
+	CONSTRAINT fk_hashtag_follows_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_hashtag_follows_hashtag FOREIGN KEY (hashtag_id) REFERENCES hashtags(id) ON DELETE CASCADE
+);
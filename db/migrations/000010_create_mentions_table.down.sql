@@ -0,0 +1,7 @@
+DELETE FROM notifications WHERE action_type = 'mention';
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying])::text[])));
+
+DROP TABLE mentions;
+DROP INDEX unique_username;
+ALTER TABLE users DROP COLUMN username;
\ No newline at end of file
@@ -0,0 +1,37 @@
+ALTER TABLE users ADD COLUMN username varchar(30) NULL;
+
+-- Existing users get a username derived from their email, suffixed with the id on collisions
+UPDATE users u
+SET username = s.base || CASE WHEN s.rn > 1 THEN '_' || u.id ELSE '' END
+FROM (
+	SELECT
+		id,
+		LEFT(regexp_replace(lower(split_part(email, '@', 1)), '[^a-z0-9_]', '_', 'g'), 20) AS base,
+		ROW_NUMBER() OVER (
+			PARTITION BY LEFT(regexp_replace(lower(split_part(email, '@', 1)), '[^a-z0-9_]', '_', 'g'), 20)
+			ORDER BY id
+		) AS rn
+	FROM users
+) s
+WHERE s.id = u.id;
+
+CREATE UNIQUE INDEX unique_username ON users (lower(username));
+
+CREATE TABLE mentions (
+	id serial4 NOT NULL,
+	post_id int4 NOT NULL,
+	comment_id int4 NULL,
+	mentioned_user_id int4 NOT NULL,
+	start_offset int4 NOT NULL,
+	end_offset int4 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT mentions_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_mentions_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	CONSTRAINT fk_mentions_comment FOREIGN KEY (comment_id) REFERENCES "comments"(id) ON DELETE CASCADE,
+	CONSTRAINT fk_mentions_user FOREIGN KEY (mentioned_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_mentions_post ON mentions (post_id, comment_id);
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying])::text[])));
@@ -0,0 +1,9 @@
+DELETE FROM notifications WHERE action_type IN ('repost', 'quote');
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying])::text[])));
+
+DELETE FROM posts WHERE post_type <> 'post';
+DROP INDEX unique_repost;
+DROP INDEX idx_posts_original_post;
+ALTER TABLE posts DROP COLUMN original_post_id;
+ALTER TABLE posts DROP COLUMN post_type;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE posts ADD COLUMN post_type varchar(10) DEFAULT 'post' NOT NULL;
+ALTER TABLE posts ADD COLUMN original_post_id int4 NULL;
+ALTER TABLE posts ADD CONSTRAINT posts_post_type_check CHECK (((post_type)::text = ANY ((ARRAY['post'::character varying, 'repost'::character varying, 'quote'::character varying])::text[])));
+ALTER TABLE posts ADD CONSTRAINT posts_original_post_check CHECK ((((post_type)::text = 'post'::text) = (original_post_id IS NULL)));
+ALTER TABLE posts ADD CONSTRAINT fk_post_original FOREIGN KEY (original_post_id) REFERENCES posts(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_posts_original_post ON posts (original_post_id);
+CREATE UNIQUE INDEX unique_repost ON posts (user_id, original_post_id) WHERE post_type = 'repost' AND deleted_at IS NULL;
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying])::text[])));
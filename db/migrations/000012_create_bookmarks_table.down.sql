@@ -0,0 +1,2 @@
+DROP TABLE bookmarks;
+DROP TABLE bookmark_collections;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE bookmark_collections (
+	id serial4 NOT NULL,
+	user_id int4 NOT NULL,
+	"name" varchar(100) NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT bookmark_collections_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_collection_name UNIQUE (user_id, "name"),
+	CONSTRAINT fk_collection_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE bookmarks (
+	id serial4 NOT NULL,
+	user_id int4 NOT NULL,
+	post_id int4 NOT NULL,
+	collection_id int4 NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT bookmarks_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_bookmark UNIQUE (user_id, post_id),
+	CONSTRAINT fk_bookmark_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_bookmark_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	CONSTRAINT fk_bookmark_collection FOREIGN KEY (collection_id) REFERENCES bookmark_collections(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_bookmarks_user ON bookmarks (user_id, id DESC);
@@ -0,0 +1,2 @@
+DROP TABLE close_friends;
+ALTER TABLE posts DROP COLUMN visibility;
\ No newline at end of file
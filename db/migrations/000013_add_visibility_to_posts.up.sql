@@ -0,0 +1,12 @@
+ALTER TABLE posts ADD COLUMN visibility varchar(20) DEFAULT 'public' NOT NULL;
+ALTER TABLE posts ADD CONSTRAINT posts_visibility_check CHECK (((visibility)::text = ANY ((ARRAY['public'::character varying, 'followers'::character varying, 'close_friends'::character varying, 'only_me'::character varying])::text[])));
+
+CREATE TABLE close_friends (
+	user_id int4 NOT NULL,
+	friend_id int4 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT close_friends_pkey PRIMARY KEY (user_id, friend_id),
+	CONSTRAINT no_self_close_friend CHECK ((user_id <> friend_id)),
+	CONSTRAINT fk_close_friends_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_close_friends_friend FOREIGN KEY (friend_id) REFERENCES users(id) ON DELETE CASCADE
+);
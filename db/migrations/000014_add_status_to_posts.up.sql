@@ -0,0 +1,6 @@
+ALTER TABLE posts ADD COLUMN status varchar(20) DEFAULT 'published' NOT NULL;
+ALTER TABLE posts ADD COLUMN publish_at timestamp NULL;
+ALTER TABLE posts ADD CONSTRAINT posts_status_check CHECK (((status)::text = ANY ((ARRAY['draft'::character varying, 'scheduled'::character varying, 'published'::character varying])::text[])));
+ALTER TABLE posts ADD CONSTRAINT scheduled_posts_publish_at CHECK (((status)::text <> 'scheduled'::text) OR (publish_at IS NOT NULL));
+
+CREATE INDEX idx_posts_scheduled ON posts (publish_at) WHERE status = 'scheduled' AND deleted_at IS NULL;
@@ -0,0 +1,7 @@
+DELETE FROM notifications WHERE action_type = 'poll_closed';
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying])::text[])));
+
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
\ No newline at end of file
@@ -0,0 +1,38 @@
+CREATE TABLE polls (
+	id serial4 NOT NULL,
+	post_id int4 NOT NULL,
+	multiple_choice bool DEFAULT false NOT NULL,
+	closes_at timestamp NOT NULL,
+	closed_notified bool DEFAULT false NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT polls_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_poll_post UNIQUE (post_id),
+	CONSTRAINT fk_poll_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_options (
+	id serial4 NOT NULL,
+	poll_id int4 NOT NULL,
+	"position" int2 NOT NULL,
+	"label" varchar(100) NOT NULL,
+	CONSTRAINT poll_options_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_poll_option_position UNIQUE (poll_id, "position"),
+	CONSTRAINT fk_poll_option_poll FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_votes (
+	poll_id int4 NOT NULL,
+	option_id int4 NOT NULL,
+	user_id int4 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT poll_votes_pkey PRIMARY KEY (poll_id, user_id, option_id),
+	CONSTRAINT fk_poll_vote_poll FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+	CONSTRAINT fk_poll_vote_option FOREIGN KEY (option_id) REFERENCES poll_options(id) ON DELETE CASCADE,
+	CONSTRAINT fk_poll_vote_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_poll_votes_option ON poll_votes (option_id);
+CREATE INDEX idx_polls_closing ON polls (closes_at) WHERE NOT closed_notified;
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying, 'poll_closed'::character varying])::text[])));
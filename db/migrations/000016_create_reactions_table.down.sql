@@ -0,0 +1,14 @@
+DELETE FROM notifications WHERE action_type = 'reaction';
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying, 'poll_closed'::character varying])::text[])));
+
+DELETE FROM reactions WHERE reaction_type <> 'like';
+DROP INDEX idx_reactions_post;
+ALTER SEQUENCE reactions_id_seq RENAME TO likes_id_seq;
+ALTER TABLE reactions RENAME CONSTRAINT fk_reactions_user TO fk_likes_user;
+ALTER TABLE reactions RENAME CONSTRAINT fk_reactions_post TO fk_likes_post;
+ALTER TABLE reactions RENAME CONSTRAINT unique_reaction TO unique_like;
+ALTER TABLE reactions RENAME CONSTRAINT reactions_pkey TO likes_pkey;
+ALTER TABLE reactions DROP COLUMN reaction_type;
+ALTER TABLE reactions RENAME COLUMN reacted_at TO liked_at;
+ALTER TABLE reactions RENAME TO likes;
\ No newline at end of file
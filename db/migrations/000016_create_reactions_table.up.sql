@@ -0,0 +1,13 @@
+ALTER TABLE likes RENAME TO reactions;
+ALTER TABLE reactions RENAME COLUMN liked_at TO reacted_at;
+ALTER TABLE reactions ADD COLUMN reaction_type varchar(20) DEFAULT 'like' NOT NULL;
+ALTER TABLE reactions RENAME CONSTRAINT likes_pkey TO reactions_pkey;
+ALTER TABLE reactions RENAME CONSTRAINT unique_like TO unique_reaction;
+ALTER TABLE reactions RENAME CONSTRAINT fk_likes_post TO fk_reactions_post;
+ALTER TABLE reactions RENAME CONSTRAINT fk_likes_user TO fk_reactions_user;
+ALTER SEQUENCE likes_id_seq RENAME TO reactions_id_seq;
+
+CREATE INDEX idx_reactions_post ON reactions (post_id, reaction_type, reacted_at DESC);
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying, 'poll_closed'::character varying, 'reaction'::character varying])::text[])));
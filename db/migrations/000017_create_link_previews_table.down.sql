@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN link_url;
+DROP TABLE link_previews;
\ No newline at end of file
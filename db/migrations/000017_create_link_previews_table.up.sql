@@ -0,0 +1,13 @@
+CREATE TABLE link_previews (
+	url varchar(2048) NOT NULL,
+	title varchar(300) NULL,
+	description text NULL,
+	image_url varchar(2048) NULL,
+	site_name varchar(200) NULL,
+	status varchar(10) NOT NULL,
+	fetched_at timestamp DEFAULT now() NOT NULL,
+	CONSTRAINT link_previews_pkey PRIMARY KEY (url),
+	CONSTRAINT link_previews_status_check CHECK (((status)::text = ANY ((ARRAY['ok'::character varying, 'failed'::character varying])::text[])))
+);
+
+ALTER TABLE posts ADD COLUMN link_url varchar(2048) NULL;
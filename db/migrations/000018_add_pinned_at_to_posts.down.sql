@@ -0,0 +1,2 @@
+DROP INDEX idx_posts_pinned;
+ALTER TABLE posts DROP COLUMN pinned_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE posts ADD COLUMN pinned_at timestamp NULL;
+
+CREATE INDEX idx_posts_pinned ON posts (user_id, pinned_at DESC) WHERE pinned_at IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN unique_viewers;
+DROP TABLE post_impressions;
\ No newline at end of file
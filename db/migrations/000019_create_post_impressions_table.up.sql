@@ -0,0 +1,10 @@
+CREATE TABLE post_impressions (
+	post_id int4 NOT NULL,
+	"day" date NOT NULL,
+	views int4 DEFAULT 0 NOT NULL,
+	unique_viewers int4 DEFAULT 0 NOT NULL,
+	CONSTRAINT post_impressions_pkey PRIMARY KEY (post_id, "day"),
+	CONSTRAINT fk_post_impressions_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+ALTER TABLE posts ADD COLUMN unique_viewers int4 DEFAULT 0 NOT NULL;
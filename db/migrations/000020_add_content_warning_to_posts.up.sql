@@ -0,0 +1,5 @@
+ALTER TABLE posts ADD COLUMN content_warning varchar(200) NULL;
+ALTER TABLE posts ADD COLUMN sensitive_media bool DEFAULT false NOT NULL;
+
+ALTER TABLE users ADD COLUMN sensitive_content varchar(10) DEFAULT 'blur' NOT NULL;
+ALTER TABLE users ADD CONSTRAINT users_sensitive_content_check CHECK (((sensitive_content)::text = ANY ((ARRAY['hide'::character varying, 'blur'::character varying, 'show'::character varying])::text[])));
@@ -0,0 +1,4 @@
+DROP INDEX unique_thread_continuation;
+DROP INDEX idx_posts_thread_root;
+ALTER TABLE posts DROP COLUMN previous_post_id;
+ALTER TABLE posts DROP COLUMN thread_root_id;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE posts ADD COLUMN thread_root_id int4 NULL;
+ALTER TABLE posts ADD COLUMN previous_post_id int4 NULL;
+ALTER TABLE posts ADD CONSTRAINT fk_posts_thread_root FOREIGN KEY (thread_root_id) REFERENCES posts(id) ON DELETE CASCADE;
+ALTER TABLE posts ADD CONSTRAINT fk_posts_previous_post FOREIGN KEY (previous_post_id) REFERENCES posts(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_posts_thread_root ON posts (thread_root_id) WHERE thread_root_id IS NOT NULL;
+CREATE UNIQUE INDEX unique_thread_continuation ON posts (previous_post_id) WHERE previous_post_id IS NOT NULL AND deleted_at IS NULL;
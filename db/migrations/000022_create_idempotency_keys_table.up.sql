@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+	user_id int4 NOT NULL,
+	idempotency_key varchar(255) NOT NULL,
+	request_hash varchar(64) NOT NULL,
+	status_code int4 NULL,
+	content_type varchar(255) NULL,
+	response_body bytea NULL,
+	created_at timestamp DEFAULT now() NULL,
+	expires_at timestamp NOT NULL,
+	CONSTRAINT idempotency_keys_pkey PRIMARY KEY (user_id, idempotency_key),
+	CONSTRAINT fk_idempotency_keys_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,6 @@
+DELETE FROM notifications WHERE action_type IN ('follow_request', 'follow_accepted');
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying, 'poll_closed'::character varying, 'reaction'::character varying])::text[])));
+
+DROP TABLE follow_requests;
+ALTER TABLE users DROP COLUMN is_private;
\ No newline at end of file
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN is_private bool DEFAULT false NOT NULL;
+
+CREATE TABLE follow_requests (
+	id serial4 NOT NULL,
+	requester_id int4 NOT NULL,
+	target_id int4 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT follow_requests_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_follow_request UNIQUE (requester_id, target_id),
+	CONSTRAINT no_self_follow_request CHECK ((requester_id <> target_id)),
+	CONSTRAINT fk_follow_requests_requester FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_follow_requests_target FOREIGN KEY (target_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_follow_requests_target ON follow_requests (target_id, id DESC);
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_action_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_action_type_check CHECK (((action_type)::text = ANY ((ARRAY['like'::character varying, 'comment'::character varying, 'follow'::character varying, 'mention'::character varying, 'repost'::character varying, 'quote'::character varying, 'poll_closed'::character varying, 'reaction'::character varying, 'follow_request'::character varying, 'follow_accepted'::character varying])::text[])));
@@ -0,0 +1 @@
+DROP TABLE blocks;
\ No newline at end of file
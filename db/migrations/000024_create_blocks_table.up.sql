@@ -0,0 +1,13 @@
+CREATE TABLE blocks (
+	id serial4 NOT NULL,
+	blocker_id int4 NOT NULL,
+	blocked_id int4 NOT NULL,
+	created_at timestamp DEFAULT now() NULL,
+	CONSTRAINT blocks_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_block UNIQUE (blocker_id, blocked_id),
+	CONSTRAINT no_self_block CHECK ((blocker_id <> blocked_id)),
+	CONSTRAINT fk_blocks_blocker FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_blocks_blocked FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_blocks_blocked ON blocks (blocked_id, blocker_id);
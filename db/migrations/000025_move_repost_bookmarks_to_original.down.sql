@@ -0,0 +1,2 @@
+-- Bookmarks moved to the original post cannot be told apart from the others, nothing to undo
+SELECT 1;
\ No newline at end of file
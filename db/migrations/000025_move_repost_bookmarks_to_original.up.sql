@@ -0,0 +1,16 @@
+-- Keep a bookmark of the original post over bookmarks of its reposts, else the oldest repost bookmark
+DELETE FROM bookmarks b
+USING posts p
+WHERE p.id = b.post_id AND p.post_type = 'repost'
+  AND EXISTS (
+	SELECT 1
+	FROM bookmarks ob
+	JOIN posts op ON op.id = ob.post_id
+	WHERE ob.user_id = b.user_id AND ob.id <> b.id
+	  AND (op.id = p.original_post_id OR (op.post_type = 'repost' AND op.original_post_id = p.original_post_id AND ob.id < b.id))
+  );
+
+UPDATE bookmarks b
+SET post_id = p.original_post_id
+FROM posts p
+WHERE p.id = b.post_id AND p.post_type = 'repost';
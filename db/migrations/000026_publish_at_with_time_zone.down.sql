@@ -0,0 +1 @@
+ALTER TABLE posts ALTER COLUMN publish_at TYPE timestamp USING publish_at AT TIME ZONE 'UTC';
\ No newline at end of file
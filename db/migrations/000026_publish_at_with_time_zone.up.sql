@@ -0,0 +1,2 @@
+-- publish_at held the wall clock sent by the client with its offset dropped, read existing values as UTC
+ALTER TABLE posts ALTER COLUMN publish_at TYPE timestamptz USING publish_at AT TIME ZONE 'UTC';
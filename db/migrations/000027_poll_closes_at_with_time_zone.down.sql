@@ -0,0 +1 @@
+ALTER TABLE polls ALTER COLUMN closes_at TYPE timestamp USING closes_at AT TIME ZONE 'UTC';
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- closes_at held the wall clock sent by the client with its offset dropped, read existing values as UTC
+ALTER TABLE polls ALTER COLUMN closes_at TYPE timestamptz USING closes_at AT TIME ZONE 'UTC';
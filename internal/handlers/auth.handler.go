@@ -47,6 +47,14 @@ func (h *AuthHandler) Register(ctx *gin.Context) {
 		return
 	}
 
+	if req.Username != "" && !utils.IsValidUsername(req.Username) {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse{
+			Success: false,
+			Error:   "username may only contain letters, numbers and underscores",
+		})
+		return
+	}
+
 	hashedPass, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -59,6 +67,13 @@ func (h *AuthHandler) Register(ctx *gin.Context) {
 
 	user, err := h.repo.RegisterUser(ctx, &req)
 	if err != nil {
+		if err.Error() == "username already taken" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "registration has failed",
@@ -69,6 +84,7 @@ func (h *AuthHandler) Register(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{
 		"success":    true,
 		"message":    "registration success",
+		"username":   user.Username,
 		"created_at": user.CreatedAt,
 	})
 }
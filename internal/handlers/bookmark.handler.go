@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type BookmarkHandler struct {
+	repo   *repositories.BookmarkRepository
+	signer *utils.MediaSigner
+	rdb    *redis.Client
+}
+
+func NewBookmarkHandler(repo *repositories.BookmarkRepository, signer *utils.MediaSigner, rdb *redis.Client) *BookmarkHandler {
+	return &BookmarkHandler{
+		repo:   repo,
+		signer: signer,
+		rdb:    rdb,
+	}
+}
+
+/* Position in the bookmark list, encoded into the opaque cursor */
+type bookmarkCursor struct {
+	BeforeID int `json:"before_id"`
+}
+
+// @Summary      Bookmark a post
+// @Description  Privately save a post, optionally into a collection. Bookmarking again moves it to the given collection
+// @ID           bookmark-post
+// @Tags         bookmark
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Param        body body models.BookmarkRequest false "target collection"
+// @Success      200 {object} models.Bookmark
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post or collection not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/bookmark [post]
+func (h *BookmarkHandler) AddBookmark(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	/* The body is optional, a plain bookmark has no collection */
+	var req models.BookmarkRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBind(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	bookmark, err := h.repo.AddBookmark(ctx, claims.UserID, postID, req.CollectionID)
+	if err != nil {
+		if err.Error() == "post not found" || err.Error() == "collection not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", claims.UserID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %d bookmarked", postID),
+		"data":    bookmark,
+	})
+}
+
+// @Summary      Remove a bookmark
+// @Description  Remove a post from your bookmarks
+// @ID           remove-bookmark
+// @Tags         bookmark
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} map[string]interface{} "Bookmark removed"
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Bookmark not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/bookmark [delete]
+func (h *BookmarkHandler) RemoveBookmark(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	if err := h.repo.RemoveBookmark(ctx, claims.UserID, postID); err != nil {
+		if err.Error() == "bookmark not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", claims.UserID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %d removed from bookmarks", postID),
+	})
+}
+
+// @Summary      Get my bookmarks
+// @Description  List bookmarked posts, newest bookmark first, with cursor pagination
+// @ID           get-bookmarks
+// @Tags         bookmark
+// @Security     BearerAuth
+// @Produce      json
+// @Param        collection_id query int false "Only bookmarks in this collection"
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        limit query int false "Posts per page" default(10)
+// @Success      200 {object} models.FeedPost
+// @Failure      400 {object} utils.ErrorResponse "Invalid cursor or collection ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/bookmarks [get]
+func (h *BookmarkHandler) GetBookmarks(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	var collectionID *int
+	if raw := ctx.Query("collection_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "invalid collection ID",
+			})
+			return
+		}
+		collectionID = &id
+	}
+
+	var beforeID *int
+	if raw := ctx.Query("cursor"); raw != "" {
+		var cursor bookmarkCursor
+		if err := utils.DecodeCursor(raw, &cursor); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		beforeID = &cursor.BeforeID
+	}
+
+	posts, nextID, err := h.repo.GetBookmarks(ctx, claims.UserID, collectionID, beforeID, utils.GetLimit(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var nextCursor *string
+	if nextID != nil {
+		cursor := utils.EncodeCursor(bookmarkCursor{BeforeID: *nextID})
+		nextCursor = &cursor
+	}
+
+	h.signer.SignFeedPosts(posts, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        posts,
+		"next_cursor": nextCursor,
+	})
+}
+
+/* ======================================================================= COLLECTIONS */
+
+// @Summary      Get my bookmark collections
+// @Description  List your bookmark collections with how many posts each holds
+// @ID           get-bookmark-collections
+// @Tags         bookmark
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.BookmarkCollection
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/bookmarks/collections [get]
+func (h *BookmarkHandler) GetCollections(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	collections, err := h.repo.GetCollections(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(collections) == 0 {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "No collections yet",
+			"data":    []interface{}{},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    collections,
+	})
+}
+
+// @Summary      Create a bookmark collection
+// @Description  Create a named collection to organize bookmarks
+// @ID           create-bookmark-collection
+// @Tags         bookmark
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body models.CollectionRequest true "collection name"
+// @Success      200 {object} models.BookmarkCollection
+// @Failure      400 {object} utils.ErrorResponse "Invalid name / Collection already exists"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/bookmarks/collections [post]
+func (h *BookmarkHandler) CreateCollection(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	var req models.CollectionRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "collection name is required",
+		})
+		return
+	}
+
+	collection, err := h.repo.CreateCollection(ctx, claims.UserID, name)
+	if err != nil {
+		if err.Error() == "collection already exists" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "collection created",
+		"data":    collection,
+	})
+}
+
+// @Summary      Delete a bookmark collection
+// @Description  Delete a collection, its bookmarks are kept without a collection
+// @ID           delete-bookmark-collection
+// @Tags         bookmark
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "collection ID"
+// @Success      200 {object} map[string]interface{} "Collection deleted"
+// @Failure      400 {object} utils.ErrorResponse "Invalid collection ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Collection not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/bookmarks/collections/{id} [delete]
+func (h *BookmarkHandler) DeleteCollection(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	collectionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid collection ID",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteCollection(ctx, claims.UserID, collectionID); err != nil {
+		if err.Error() == "collection not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "collection deleted",
+	})
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
@@ -13,14 +15,16 @@ import (
 )
 
 type FeedHandler struct {
-	repo *repositories.FeedRepository
-	rdb  *redis.Client
+	repo   *repositories.FeedRepository
+	signer *utils.MediaSigner
+	rdb    *redis.Client
 }
 
-func NewFeedHandler(repo *repositories.FeedRepository, rdb *redis.Client) *FeedHandler {
+func NewFeedHandler(repo *repositories.FeedRepository, signer *utils.MediaSigner, rdb *redis.Client) *FeedHandler {
 	return &FeedHandler{
-		repo: repo,
-		rdb:  rdb,
+		repo:   repo,
+		signer: signer,
+		rdb:    rdb,
 	}
 }
 
@@ -47,7 +51,7 @@ func (h *FeedHandler) GetUserFeed(ctx *gin.Context) {
 	claims := rawClaims.(*utils.Claims)
 	userID := claims.UserID
 
-	redisKey := "feed:post"
+	redisKey := fmt.Sprintf("feed:post:%d", userID)
 	var cached []models.FeedPost
 	if h.rdb != nil {
 		err := utils.GetCache(ctx, h.rdb, redisKey, &cached)
@@ -55,6 +59,8 @@ func (h *FeedHandler) GetUserFeed(ctx *gin.Context) {
 			log.Panicln("Redis error, back to DB : ", err)
 		}
 		if len(cached) > 0 {
+			utils.TrackImpressions(ctx, h.rdb, userID, cached)
+			h.signer.SignFeedPosts(cached, userID)
 			ctx.JSON(http.StatusOK, gin.H{
 				"success": true,
 				"data":    cached,
@@ -89,8 +95,71 @@ func (h *FeedHandler) GetUserFeed(ctx *gin.Context) {
 		}
 	}
 
+	utils.TrackImpressions(ctx, h.rdb, userID, feed)
+
+	/* Sign after caching, the cache only holds stored paths */
+	h.signer.SignFeedPosts(feed, userID)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    feed,
 	})
 }
+
+// @Summary Get user posts
+// @Description Profile timeline of a user, pinned posts first with is_pinned set, then newest first
+// @ID get-user-posts
+// @Tags feed
+// @Security     BearerAuth
+// @Produce json
+// @Param id path int true "user ID"
+// @Param page query int false "page number"
+// @Param limit query int false "page size"
+// @Success 200 {object} models.FeedPost
+// @Failure 400 {object} utils.ErrorResponse "Invalid user ID"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /user/{id}/posts [get]
+func (h *FeedHandler) GetUserPosts(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	page, limit, offset := utils.GetPagination(ctx)
+
+	posts, err := h.repo.GetUserPosts(ctx, claims.UserID, userID, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if posts == nil {
+		posts = []models.FeedPost{}
+	}
+
+	h.signer.SignFeedPosts(posts, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    posts,
+		"page":    page,
+		"limit":   limit,
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type InsightHandler struct {
+	repo *repositories.InsightRepository
+}
+
+func NewInsightHandler(repo *repositories.InsightRepository) *InsightHandler {
+	return &InsightHandler{
+		repo: repo,
+	}
+}
+
+// @Summary      Get post insights
+// @Description  Views, unique viewers, likes and comments of your own post, with daily activity of the last 30 days. Views are flushed from the counters periodically
+// @ID           get-post-insights
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} models.PostInsights
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/insights [get]
+func (h *InsightHandler) GetInsights(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	insights, err := h.repo.GetInsights(ctx, postID, claims.UserID)
+	if err != nil {
+		if err.Error() == "post not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    insights,
+	})
+}
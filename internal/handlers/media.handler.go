@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MediaHandler struct {
+	repo   *repositories.PostRepository
+	signer *utils.MediaSigner
+}
+
+func NewMediaHandler(repo *repositories.PostRepository, signer *utils.MediaSigner) *MediaHandler {
+	return &MediaHandler{
+		repo:   repo,
+		signer: signer,
+	}
+}
+
+// @Summary      Get post media
+// @Description  Serve an uploaded post file through a signed, expiring URL
+// @ID           get-media
+// @Tags         media
+// @Produce      octet-stream
+// @Param        path path string true "Stored file path"
+// @Param        uid query int true "Viewer ID the URL was signed for"
+// @Param        exp query int true "Expiry as unix timestamp"
+// @Param        sig query string true "URL signature"
+// @Success      200 {file} file
+// @Failure      400 {object} utils.ErrorResponse "Invalid media link"
+// @Failure      403 {object} utils.ErrorResponse "Expired or invalid signature"
+// @Failure      404 {object} utils.ErrorResponse "Media not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /media/{path} [get]
+func (h *MediaHandler) ServeMedia(ctx *gin.Context) {
+	path := ctx.Param("path")
+
+	viewerID, err := strconv.Atoi(ctx.Query("uid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid media link",
+		})
+		return
+	}
+
+	exp, err := strconv.ParseInt(ctx.Query("exp"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid media link",
+		})
+		return
+	}
+
+	if err := h.signer.Verify(path, viewerID, exp, ctx.Query("sig")); err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	/* The link may outlive the viewer's access, so check the owning post again */
+	allowed, err := h.repo.CanViewMedia(ctx, path, viewerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !allowed {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "media not found",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=900")
+	ctx.File(utils.UploadDiskPath(path))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type PollHandler struct {
+	repo *repositories.PollRepository
+	rdb  *redis.Client
+}
+
+func NewPollHandler(repo *repositories.PollRepository, rdb *redis.Client) *PollHandler {
+	return &PollHandler{
+		repo: repo,
+		rdb:  rdb,
+	}
+}
+
+// @Summary      Vote in a poll
+// @Description  Vote in the poll of a post, once per user. Single choice polls accept exactly one option
+// @ID           vote-poll
+// @Tags         post
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Param        body body models.VoteRequest true "chosen options"
+// @Success      200 {object} models.Poll "Updated poll results"
+// @Failure      400 {object} utils.ErrorResponse "Invalid option / Already voted / Poll is closed"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Poll not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/vote [post]
+func (h *PollHandler) Vote(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	var req models.VoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	poll, err := h.repo.Vote(ctx, postID, claims.UserID, req.OptionIDs)
+	if err != nil {
+		switch err.Error() {
+		case "poll not found":
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		case "poll is closed", "already voted", "only one option can be chosen", "invalid poll option":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	/* Cached feeds hold the previous counts */
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "vote recorded",
+		"data":    poll,
+	})
+}
@@ -5,23 +5,39 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
 	"github.com/febryanhernanda/social-media-apps/internal/repositories"
 	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/febryanhernanda/social-media-apps/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
 type PostHandler struct {
-	repo *repositories.PostRepository
-	rdb  *redis.Client
+	repo       *repositories.PostRepository
+	uploadRepo *repositories.UploadRepository
+	signer     *utils.MediaSigner
+	previews   *workers.LinkPreviewQueue
+	rdb        *redis.Client
 }
 
-func NewPostHandler(repo *repositories.PostRepository, rdb *redis.Client) *PostHandler {
+func NewPostHandler(repo *repositories.PostRepository, uploadRepo *repositories.UploadRepository, signer *utils.MediaSigner, previews *workers.LinkPreviewQueue, rdb *redis.Client) *PostHandler {
 	return &PostHandler{
-		repo: repo,
-		rdb:  rdb,
+		repo:       repo,
+		uploadRepo: uploadRepo,
+		signer:     signer,
+		previews:   previews,
+		rdb:        rdb,
+	}
+}
+
+/* Fetch the preview card of the post link in the background when it is not cached yet */
+func (h *PostHandler) queueLinkPreview(post *models.Post) {
+	if post.LinkURL != nil && post.LinkPreview == nil {
+		h.previews.Enqueue(*post.LinkURL)
 	}
 }
 
@@ -34,6 +50,16 @@ func NewPostHandler(repo *repositories.PostRepository, rdb *redis.Client) *PostH
 // @Produce      json
 // @Param        content formData string true "Post content"
 // @Param        image   formData file false "Post image file"
+// @Param        upload_id formData string false "ID of a completed resumable upload, instead of image"
+// @Param        visibility formData string false "public (default), followers, close_friends or only_me"
+// @Param        status formData string false "draft or published (default)"
+// @Param        publish_at formData string false "RFC3339 time to publish the post at, makes it a scheduled post"
+// @Param        poll_options formData []string false "2 to 4 poll options"
+// @Param        poll_closes_at formData string false "RFC3339 time the poll closes at, required with poll_options"
+// @Param        poll_multiple formData bool false "allow choosing several poll options"
+// @Param        content_warning formData string false "content warning shown instead of the post, at most 200 characters"
+// @Param        sensitive_media formData bool false "the image is sensitive and gets blurred or hidden"
+// @Param        previous_post_id formData int false "ID of your own post this one continues as a thread"
 // @Success      200 {object} models.Post "Post created successfully"
 // @Failure      400 {object} utils.ErrorResponse "Bad request"
 // @Failure      401 {object} utils.ErrorResponse "Unauthorized"
@@ -59,11 +85,92 @@ func (h *PostHandler) CreatePost(ctx *gin.Context) {
 		return
 	}
 
+	status := req.Status
+	req.PublishAt = utils.ClientTimeUTC(req.PublishAt)
+	if req.PublishAt != nil {
+		if status == "draft" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "a draft cannot have a publish time",
+			})
+			return
+		}
+		if !req.PublishAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "publish_at must be in the future",
+			})
+			return
+		}
+		status = "scheduled"
+	}
+
+	var poll *models.Poll
+	req.PollClosesAt = utils.ClientTimeUTC(req.PollClosesAt)
+	if len(req.PollOptions) > 0 {
+		if req.PollClosesAt == nil || !req.PollClosesAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "poll_closes_at must be in the future",
+			})
+			return
+		}
+		if req.PublishAt != nil && !req.PollClosesAt.After(*req.PublishAt) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "poll must close after the post is published",
+			})
+			return
+		}
+
+		poll = &models.Poll{
+			MultipleChoice: req.PollMultiple,
+			ClosesAt:       *req.PollClosesAt,
+		}
+		for _, label := range req.PollOptions {
+			label = strings.TrimSpace(label)
+			if label == "" {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error":   "poll options cannot be empty",
+				})
+				return
+			}
+			poll.Options = append(poll.Options, models.PollOption{Label: label})
+		}
+	} else if req.PollClosesAt != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "a poll needs between 2 and 4 options",
+		})
+		return
+	}
+
 	var filePath *string = nil
 
 	_, err := ctx.FormFile("image")
+	if err == nil && req.UploadID != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "send either an image or an upload_id, not both",
+		})
+		return
+	}
+
+	if req.UploadID != "" {
+		uploadedPath, err := h.uploadRepo.GetCompletedTusUpload(ctx, req.UploadID, claims.UserID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		filePath = &uploadedPath
+	}
+
 	if err == nil {
-		uploadedPath, err := utils.UploadFile(ctx, "image", "public/post", "post", "post")
+		upload, err := utils.UploadFile(ctx, "image", "public/post", "post", "post", h.uploadRepo.RegisterUpload)
 		if err != nil {
 			log.Printf("[DEBUG] ERRORS : %s", err.Error())
 			ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -72,49 +179,77 @@ func (h *PostHandler) CreatePost(ctx *gin.Context) {
 			})
 			return
 		}
-		filePath = &uploadedPath
+		filePath = &upload.Path
 	}
 	post := &models.Post{
-		Content:   req.Content,
-		ImagePath: filePath,
-		UserID:    claims.UserID,
+		Content:        req.Content,
+		ImagePath:      filePath,
+		UserID:         claims.UserID,
+		Visibility:     req.Visibility,
+		Status:         status,
+		PublishAt:      req.PublishAt,
+		Poll:           poll,
+		SensitiveMedia: req.SensitiveMedia,
+		PreviousPostID: req.PreviousPostID,
+	}
+	if warning := strings.TrimSpace(req.ContentWarning); warning != "" {
+		post.ContentWarning = &warning
 	}
 
 	newPost, err := h.repo.CreatePost(ctx, post)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "failed to create post",
-		})
+		switch err.Error() {
+		case "previous post not found", "thread already continued from this post":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "failed to create post",
+			})
+		}
 		return
 	}
 
-	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
-		log.Println("Redis delete cache error:", err)
+	h.queueLinkPreview(newPost)
+
+	message := "post created successfully"
+	switch newPost.Status {
+	case "draft":
+		message = "draft saved successfully"
+	case "scheduled":
+		message = "post scheduled successfully"
+	default:
+		if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+			log.Println("Redis delete cache error:", err)
+		}
 	}
 
+	newPost.ImagePath = h.signer.SignPath(newPost.ImagePath, claims.UserID)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "post created successfully",
+		"message": message,
 		"data":    newPost,
 	})
 }
 
-/* ======================================================================= LIKE POST */
-
-// @Summary Like a post
-// @Description Like a post with user ID
-// @ID like-post
-// @Tags post
+// @Summary      Get a post
+// @Description  Get a single post, posts the viewer is not allowed to see are reported as not found
+// @ID           get-post
+// @Tags         post
 // @Security     BearerAuth
-// @Accept json
-// @Produce json
-// @Param id path int true "post ID"
-// @Success 200 {object} models.Like
-// @Failure 400 {object} utils.ErrorResponse
-// @Failure 500 {object} utils.ErrorResponse
-// @Router /post/{id}/like [post]
-func (h *PostHandler) LikePost(ctx *gin.Context) {
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} models.FeedPost
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id} [get]
+func (h *PostHandler) GetPost(ctx *gin.Context) {
 	rawClaims, exists := ctx.Get("claims")
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
@@ -124,33 +259,84 @@ func (h *PostHandler) LikePost(ctx *gin.Context) {
 		return
 	}
 	claims := rawClaims.(*utils.Claims)
-	userID := claims.UserID
 
-	postIDStr := ctx.Param("id")
-	postID, err := strconv.Atoi(postIDStr)
+	postID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "invalid user ID",
+			"error":   "invalid post ID",
 		})
 		return
 	}
 
-	postOwnerID, err := h.repo.GetPostOwnerID(ctx, postID)
+	post, err := h.repo.GetPost(ctx, postID, claims.UserID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
+		if err.Error() == "post not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
-		log.Println("Redis delete cache error:", err)
+	posts := []models.FeedPost{*post}
+	utils.TrackImpressions(ctx, h.rdb, claims.UserID, posts)
+	h.signer.SignFeedPosts(posts, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    posts[0],
+	})
+}
+
+// @Summary      Get a thread
+// @Description  Get the chain of posts of the thread the post belongs to, root first, leaving out posts you cannot see
+// @ID           get-thread
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of any post of the thread"
+// @Success      200 {object} models.FeedPost
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/thread [get]
+func (h *PostHandler) GetThread(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
 	}
 
-	like, err := h.repo.LikePost(ctx, postID, userID, postOwnerID)
+	thread, err := h.repo.GetThread(ctx, postID, claims.UserID)
 	if err != nil {
+		if err.Error() == "post not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -158,26 +344,30 @@ func (h *PostHandler) LikePost(ctx *gin.Context) {
 		return
 	}
 
+	h.signer.SignFeedPosts(thread, claims.UserID)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": fmt.Sprintf("post id %s liked", postIDStr),
-		"data":    like,
+		"data":    thread,
 	})
 }
 
-// @Summary Unlike a post
-// @Description Unlike a post with user ID
-// @ID unlike-post
-// @Tags post
+// @Summary      Edit a post
+// @Description  Edit the content of your own post, hashtags are parsed again
+// @ID           update-post
+// @Tags         post
 // @Security     BearerAuth
-// @Accept json
-// @Produce json
-// @Param id path int true "post ID"
-// @Success 200 {object} models.Like
-// @Failure 400 {object} utils.ErrorResponse
-// @Failure 500 {object} utils.ErrorResponse
-// @Router /post/{id}/unlike [delete]
-func (h *PostHandler) UnlikePost(ctx *gin.Context) {
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Param        body body models.UpdatePostRequest true "new post content"
+// @Success      200 {object} models.Post
+// @Failure      400 {object} utils.ErrorResponse "Bad request"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id} [patch]
+func (h *PostHandler) UpdatePost(ctx *gin.Context) {
 	rawClaims, exists := ctx.Get("claims")
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
@@ -187,20 +377,18 @@ func (h *PostHandler) UnlikePost(ctx *gin.Context) {
 		return
 	}
 	claims := rawClaims.(*utils.Claims)
-	userID := claims.UserID
 
-	postIDStr := ctx.Param("id")
-	postID, err := strconv.Atoi(postIDStr)
+	postID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "invalid user ID",
+			"error":   "invalid post ID",
 		})
 		return
 	}
 
-	err = h.repo.UnlikePost(ctx, postID, userID)
-	if err != nil {
+	var req models.UpdatePostRequest
+	if err := ctx.ShouldBind(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -208,32 +396,54 @@ func (h *PostHandler) UnlikePost(ctx *gin.Context) {
 		return
 	}
 
+	post, err := h.repo.UpdatePost(ctx, postID, claims.UserID, req.Content)
+	if err != nil {
+		if err.Error() == "post not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.queueLinkPreview(post)
+
 	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
 		log.Println("Redis delete cache error:", err)
 	}
 
+	post.ImagePath = h.signer.SignPath(post.ImagePath, claims.UserID)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": fmt.Sprintf("post id %s unliked", postIDStr),
+		"message": "post updated successfully",
+		"data":    post,
 	})
 }
 
-/* ======================================================================= COMMENT POST */
+/* ======================================================================= LIKE POST */
 
-// @Summary Add a comment to a post
-// @Description Add a comment to a post with the given content
-// @ID add-comment
+// @Summary React to a post
+// @Description Set your reaction to a post, replacing any previous one. GET /post/reactions/types lists the available types
+// @ID react-post
 // @Tags post
 // @Security     BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "post ID"
-// @Param body body models.CommentRequest true "comment request body"
-// @Success 200 {object} models.Comment
-// @Failure 400 {object} utils.ErrorResponse
+// @Param body body models.ReactionRequest true "reaction type"
+// @Success 200 {object} models.Reaction
+// @Failure 400 {object} utils.ErrorResponse "Invalid post ID / Unknown reaction type"
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
 // @Failure 500 {object} utils.ErrorResponse
-// @Router /post/{id}/comment [post]
-func (h *PostHandler) AddComment(ctx *gin.Context) {
+// @Router /post/{id}/reaction [put]
+func (h *PostHandler) React(ctx *gin.Context) {
 	rawClaims, exists := ctx.Get("claims")
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
@@ -243,12 +453,17 @@ func (h *PostHandler) AddComment(ctx *gin.Context) {
 		return
 	}
 	claims := rawClaims.(*utils.Claims)
-	userID := claims.UserID
 
-	postIDStr := ctx.Param("id")
-	postID, _ := strconv.Atoi(postIDStr)
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
 
-	var req models.CommentRequest
+	var req models.ReactionRequest
 	if err := ctx.ShouldBind(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -257,22 +472,75 @@ func (h *PostHandler) AddComment(ctx *gin.Context) {
 		return
 	}
 
-	postOwnerID, err := h.repo.GetPostOwnerID(ctx, postID)
+	if !utils.IsReactionType(req.Type) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown reaction type, use one of: %s", strings.Join(utils.ReactionTypes(), ", ")),
+		})
+		return
+	}
+
+	postOwnerID, err := h.repo.GetPostOwnerID(ctx, postID, claims.UserID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "post not found",
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	comment := &models.Comment{
-		PostID:  postID,
-		UserID:  userID,
-		Content: req.Content,
+	reaction, err := h.repo.React(ctx, postID, claims.UserID, postOwnerID, req.Type)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	comment, err = h.repo.AddComment(ctx, comment, postOwnerID)
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("reacted %s to post id %d", reaction.Type, postID),
+		"data":    reaction,
+	})
+}
+
+// @Summary Remove a reaction
+// @Description Remove your reaction to a post
+// @ID remove-reaction
+// @Tags post
+// @Security     BearerAuth
+// @Produce json
+// @Param id path int true "post ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse "Invalid post ID / Reaction not found"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/reaction [delete]
+func (h *PostHandler) RemoveReaction(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	removed, err := h.repo.RemoveReaction(ctx, postID, claims.UserID, "")
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -281,13 +549,1052 @@ func (h *PostHandler) AddComment(ctx *gin.Context) {
 		return
 	}
 
+	if !removed {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "reaction not found",
+		})
+		return
+	}
+
 	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
 		log.Println("Redis delete cache error:", err)
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "comment added",
-		"data":    comment,
+		"message": fmt.Sprintf("reaction to post id %d removed", postID),
+	})
+}
+
+// @Summary List reactions
+// @Description List the users who reacted to a post, most recent first
+// @ID get-reactions
+// @Tags post
+// @Security     BearerAuth
+// @Produce json
+// @Param id path int true "post ID"
+// @Param type query string false "only this reaction type"
+// @Param page query int false "page number"
+// @Param limit query int false "page size"
+// @Success 200 {object} models.ReactionUser
+// @Failure 400 {object} utils.ErrorResponse "Invalid post ID / Unknown reaction type"
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/reactions [get]
+func (h *PostHandler) GetReactions(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	reactionType := ctx.Query("type")
+	if reactionType != "" && !utils.IsReactionType(reactionType) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown reaction type, use one of: %s", strings.Join(utils.ReactionTypes(), ", ")),
+		})
+		return
+	}
+
+	if _, err := h.repo.GetPostOwnerID(ctx, postID, claims.UserID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	page, limit, offset := utils.GetPagination(ctx)
+
+	users, err := h.repo.GetReactions(ctx, postID, claims.UserID, reactionType, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"page":    page,
+		"limit":   limit,
+		"data":    users,
+	})
+}
+
+// @Summary      List likes
+// @Description  List the users who liked a post with cursor pagination, users you follow first, then most recent first
+// @ID           get-likes
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        limit query int false "Users per page" default(10)
+// @Success      200 {object} models.LikeUser
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID or cursor"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/likes [get]
+func (h *PostHandler) GetLikes(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	var after *models.LikesCursor
+	if raw := ctx.Query("cursor"); raw != "" {
+		var cursor models.LikesCursor
+		if err := utils.DecodeCursor(raw, &cursor); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		after = &cursor
+	}
+
+	if _, err := h.repo.GetPostOwnerID(ctx, postID, claims.UserID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	users, next, err := h.repo.GetLikes(ctx, postID, claims.UserID, after, utils.GetLimit(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var nextCursor *string
+	if next != nil {
+		cursor := utils.EncodeCursor(next)
+		nextCursor = &cursor
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        users,
+		"next_cursor": nextCursor,
+	})
+}
+
+// @Summary List reaction types
+// @Description List the reaction types that can be used
+// @ID get-reaction-types
+// @Tags post
+// @Security     BearerAuth
+// @Produce json
+// @Success 200 {object} []string
+// @Router /post/reactions/types [get]
+func (h *PostHandler) GetReactionTypes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    utils.ReactionTypes(),
+	})
+}
+
+// @Summary Like a post
+// @Description Like a post with user ID, an alias for reacting with "like". Liking again is a no-op
+// @ID like-post
+// @Tags post
+// @Security     BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "post ID"
+// @Success 200 {object} models.LikeState
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/like [post]
+func (h *PostHandler) LikePost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+	userID := claims.UserID
+
+	postIDStr := ctx.Param("id")
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	postOwnerID, err := h.repo.GetPostOwnerID(ctx, postID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if _, err := h.repo.React(ctx, postID, userID, postOwnerID, "like"); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	state, err := h.repo.GetLikeState(ctx, postID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %s liked", postIDStr),
+		"data":    state,
+	})
+}
+
+// @Summary Unlike a post
+// @Description Unlike a post with user ID. Unliking a post that is not liked is a no-op
+// @ID unlike-post
+// @Tags post
+// @Security     BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "post ID"
+// @Success 200 {object} models.LikeState
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/unlike [delete]
+func (h *PostHandler) UnlikePost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+	userID := claims.UserID
+
+	postIDStr := ctx.Param("id")
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	if _, err := h.repo.GetPostOwnerID(ctx, postID, userID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	/* Unliking a post that is not liked is a no-op, other reactions are left alone */
+	removed, err := h.repo.RemoveReaction(ctx, postID, userID, "like")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if removed {
+		if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+			log.Println("Redis delete cache error:", err)
+		}
+	}
+
+	state, err := h.repo.GetLikeState(ctx, postID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %s unliked", postIDStr),
+		"data":    state,
+	})
+}
+
+/* ======================================================================= COMMENT POST */
+
+// @Summary Add a comment to a post
+// @Description Add a comment to a post with the given content
+// @ID add-comment
+// @Tags post
+// @Security     BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "post ID"
+// @Param body body models.CommentRequest true "comment request body"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/comment [post]
+func (h *PostHandler) AddComment(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+	userID := claims.UserID
+
+	postIDStr := ctx.Param("id")
+	postID, _ := strconv.Atoi(postIDStr)
+
+	var req models.CommentRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	postOwnerID, err := h.repo.GetPostOwnerID(ctx, postID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "post not found",
+		})
+		return
+	}
+
+	comment := &models.Comment{
+		PostID:  postID,
+		UserID:  userID,
+		Content: req.Content,
+	}
+
+	comment, err = h.repo.AddComment(ctx, comment, postOwnerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "comment added",
+		"data":    comment,
+	})
+}
+
+/* ======================================================================= REPOST */
+
+// @Summary Repost a post
+// @Description Share a post to your followers, reposting a repost shares the original
+// @ID repost-post
+// @Tags post
+// @Security     BearerAuth
+// @Produce json
+// @Param id path int true "post ID"
+// @Success 200 {object} models.Post
+// @Failure 400 {object} utils.ErrorResponse "Invalid post ID / Already reposted / Post is not public"
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/repost [post]
+func (h *PostHandler) RepostPost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	originalID, visibility, err := h.repo.GetOriginalPostID(ctx, postID, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if visibility != "public" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "only public posts can be shared",
+		})
+		return
+	}
+
+	repost, err := h.repo.CreatePost(ctx, &models.Post{
+		UserID:         claims.UserID,
+		PostType:       "repost",
+		OriginalPostID: &originalID,
+	})
+	if err != nil {
+		if err.Error() == "already reposted" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %d reposted", originalID),
+		"data":    repost,
+	})
+}
+
+// @Summary Undo a repost
+// @Description Remove your repost of a post
+// @ID undo-repost
+// @Tags post
+// @Security     BearerAuth
+// @Produce json
+// @Param id path int true "post ID"
+// @Success 200 {object} map[string]interface{} "Repost removed"
+// @Failure 400 {object} utils.ErrorResponse "Invalid post ID / Not reposted"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/repost [delete]
+func (h *PostHandler) UndoRepost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	originalID, err := h.repo.UndoRepost(ctx, postID, claims.UserID)
+	if err != nil {
+		if err.Error() == "not reposted" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("repost of post id %d removed", originalID),
+	})
+}
+
+// @Summary Quote a post
+// @Description Share a post with your own commentary
+// @ID quote-post
+// @Tags post
+// @Security     BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "post ID"
+// @Param body body models.QuotePostRequest true "quote commentary"
+// @Success 200 {object} models.Post
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /post/{id}/quote [post]
+func (h *PostHandler) QuotePost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	var req models.QuotePostRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	originalID, visibility, err := h.repo.GetOriginalPostID(ctx, postID, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if visibility != "public" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "only public posts can be shared",
+		})
+		return
+	}
+
+	quote, err := h.repo.CreatePost(ctx, &models.Post{
+		Content:        req.Content,
+		UserID:         claims.UserID,
+		PostType:       "quote",
+		OriginalPostID: &originalID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "failed to create post",
+		})
+		return
+	}
+
+	h.queueLinkPreview(quote)
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "post quoted successfully",
+		"data":    quote,
+	})
+}
+
+/* ======================================================================= DRAFTS */
+
+// @Summary      Get drafts
+// @Description  List your drafts and scheduled posts, the next to be published first
+// @ID           get-drafts
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.Post
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/drafts [get]
+func (h *PostHandler) GetDrafts(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	drafts, err := h.repo.GetDrafts(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for i := range drafts {
+		drafts[i].ImagePath = h.signer.SignPath(drafts[i].ImagePath, claims.UserID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    drafts,
+	})
+}
+
+// @Summary      Schedule a draft
+// @Description  Set or change the time a draft or scheduled post gets published
+// @ID           schedule-post
+// @Tags         post
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Param        body body models.SchedulePostRequest true "publish time"
+// @Success      200 {object} models.Post
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID / Publish time not in the future"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Draft not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/schedule [put]
+func (h *PostHandler) SchedulePost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	var req models.SchedulePostRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !req.PublishAt.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "publish_at must be in the future",
+		})
+		return
+	}
+
+	post, err := h.repo.SchedulePost(ctx, postID, claims.UserID, utils.ClientTimeUTC(&req.PublishAt))
+	if err != nil {
+		if err.Error() == "draft not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	post.ImagePath = h.signer.SignPath(post.ImagePath, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "post scheduled successfully",
+		"data":    post,
+	})
+}
+
+// @Summary      Unschedule a post
+// @Description  Move a scheduled post back to drafts
+// @ID           unschedule-post
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} models.Post
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Draft not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/schedule [delete]
+func (h *PostHandler) UnschedulePost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	post, err := h.repo.SchedulePost(ctx, postID, claims.UserID, nil)
+	if err != nil {
+		if err.Error() == "draft not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	post.ImagePath = h.signer.SignPath(post.ImagePath, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "post moved back to drafts",
+		"data":    post,
+	})
+}
+
+// @Summary      Publish a draft
+// @Description  Publish a draft or scheduled post right away
+// @ID           publish-post
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} models.Post
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Draft not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/publish [post]
+func (h *PostHandler) PublishPost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	post, err := h.repo.PublishPost(ctx, postID, claims.UserID)
+	if err != nil {
+		if err.Error() == "draft not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	post.ImagePath = h.signer.SignPath(post.ImagePath, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "post published successfully",
+		"data":    post,
+	})
+}
+
+// @Summary      Delete a draft
+// @Description  Discard a draft or scheduled post
+// @ID           delete-draft
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} map[string]interface{} "Draft deleted"
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Draft not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/drafts/{id} [delete]
+func (h *PostHandler) DeleteDraft(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteDraft(ctx, postID, claims.UserID); err != nil {
+		if err.Error() == "draft not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("draft id %d deleted", postID),
+	})
+}
+
+/* ======================================================================= PINS */
+
+// @Summary      Pin a post
+// @Description  Pin one of your posts to the top of your profile, a few posts can be pinned at once
+// @ID           pin-post
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} map[string]interface{} "Post pinned"
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID / Already pinned / Pin limit reached"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Post not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/pin [post]
+func (h *PostHandler) PinPost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	if err := h.repo.PinPost(ctx, postID, claims.UserID); err != nil {
+		switch err.Error() {
+		case "post not found":
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		case "post already pinned":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		case "pin limit reached":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("you can pin at most %d posts, unpin one first", repositories.MaxPinnedPosts),
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %d pinned", postID),
+	})
+}
+
+// @Summary      Unpin a post
+// @Description  Remove a post from the top of your profile
+// @ID           unpin-post
+// @Tags         post
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "post ID"
+// @Success      200 {object} map[string]interface{} "Post unpinned"
+// @Failure      400 {object} utils.ErrorResponse "Invalid post ID / Post not pinned"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /post/{id}/pin [delete]
+func (h *PostHandler) UnpinPost(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	postID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid post ID",
+		})
+		return
+	}
+
+	if err := h.repo.UnpinPost(ctx, postID, claims.UserID); err != nil {
+		if err.Error() == "post not pinned" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("post id %d unpinned", postID),
 	})
 }
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type TagHandler struct {
+	repo   *repositories.TagRepository
+	signer *utils.MediaSigner
+	rdb    *redis.Client
+}
+
+func NewTagHandler(repo *repositories.TagRepository, signer *utils.MediaSigner, rdb *redis.Client) *TagHandler {
+	return &TagHandler{
+		repo:   repo,
+		signer: signer,
+		rdb:    rdb,
+	}
+}
+
+// @Summary      Get posts by hashtag
+// @Description  List posts tagged with a hashtag, newest first
+// @ID           get-tag-posts
+// @Tags         tags
+// @Security     BearerAuth
+// @Produce      json
+// @Param        tag path string true "Hashtag, with or without #"
+// @Param        page query int false "Page number" default(1)
+// @Param        limit query int false "Posts per page" default(10)
+// @Success      200 {object} models.FeedPost
+// @Failure      400 {object} utils.ErrorResponse "Invalid hashtag"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /tags/{tag} [get]
+func (h *TagHandler) GetPostsByTag(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	tag, ok := utils.NormalizeHashtag(ctx.Param("tag"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid hashtag",
+		})
+		return
+	}
+
+	page, limit, offset := utils.GetPagination(ctx)
+
+	posts, err := h.repo.GetPostsByTag(ctx, claims.UserID, tag, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(posts) == 0 {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": fmt.Sprintf("No posts found for #%s", tag),
+			"data":    []interface{}{},
+			"page":    page,
+			"limit":   limit,
+		})
+		return
+	}
+
+	h.signer.SignFeedPosts(posts, claims.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    posts,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// @Summary      Follow a hashtag
+// @Description  Follow a hashtag so its posts show up in the feed
+// @ID           follow-tag
+// @Tags         tags
+// @Security     BearerAuth
+// @Produce      json
+// @Param        tag path string true "Hashtag, with or without #"
+// @Success      200 {object} models.Hashtag
+// @Failure      400 {object} utils.ErrorResponse "Invalid hashtag / Already following"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /tags/{tag}/follow [post]
+func (h *TagHandler) FollowHashtag(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	tag, ok := utils.NormalizeHashtag(ctx.Param("tag"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid hashtag",
+		})
+		return
+	}
+
+	hashtag, err := h.repo.FollowHashtag(ctx, claims.UserID, tag)
+	if err != nil {
+		if err.Error() == "already following this hashtag" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", claims.UserID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("You are now following #%s", hashtag.Name),
+		"data":    hashtag,
+	})
+}
+
+// @Summary      Unfollow a hashtag
+// @Description  Stop seeing posts of a hashtag in the feed
+// @ID           unfollow-tag
+// @Tags         tags
+// @Security     BearerAuth
+// @Produce      json
+// @Param        tag path string true "Hashtag, with or without #"
+// @Success      200 {object} map[string]interface{} "Unfollowed successfully"
+// @Failure      400 {object} utils.ErrorResponse "Invalid hashtag / Not following"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /tags/{tag}/follow [delete]
+func (h *TagHandler) UnfollowHashtag(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	tag, ok := utils.NormalizeHashtag(ctx.Param("tag"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid hashtag",
+		})
+		return
+	}
+
+	if err := h.repo.UnfollowHashtag(ctx, claims.UserID, tag); err != nil {
+		if err.Error() == "not following this hashtag" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", claims.UserID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "unfollowed successfully",
+	})
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UploadHandler struct {
+	repo *repositories.UploadRepository
+}
+
+func NewUploadHandler(repo *repositories.UploadRepository) *UploadHandler {
+	return &UploadHandler{
+		repo: repo,
+	}
+}
+
+// @Summary      Tus server capabilities
+// @Description  Report the supported tus version, extensions and maximum upload size
+// @ID           tus-options
+// @Tags         upload
+// @Success      204 "Capabilities in the response headers"
+// @Router       /upload [options]
+func (h *UploadHandler) Options(ctx *gin.Context) {
+	ctx.Header("Tus-Version", utils.TusVersion)
+	ctx.Header("Tus-Extension", utils.TusExtensions)
+	ctx.Header("Tus-Max-Size", strconv.Itoa(utils.TusMaxSize))
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary      Create a resumable upload
+// @Description  Start a tus upload, the Location header holds the URL to PATCH the data to
+// @ID           tus-create
+// @Tags         upload
+// @Security     BearerAuth
+// @Param        Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param        Upload-Length header int true "Total size in bytes"
+// @Param        Upload-Metadata header string false "Comma separated key and base64 value pairs, e.g. filename"
+// @Success      201 "Upload created"
+// @Failure      400 {object} utils.ErrorResponse "Invalid Upload-Length or Upload-Metadata"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      413 {object} utils.ErrorResponse "Upload too large"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /upload [post]
+func (h *UploadHandler) CreateUpload(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	length, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid Upload-Length header",
+		})
+		return
+	}
+
+	if length > utils.TusMaxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("upload exceeds the maximum size of %d bytes", utils.TusMaxSize),
+		})
+		return
+	}
+
+	var metadata *string
+	if header := ctx.GetHeader("Upload-Metadata"); header != "" {
+		if _, err := utils.ParseTusMetadata(header); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		metadata = &header
+	}
+
+	uploadID, err := utils.NewTusUploadID()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "failed to create upload",
+		})
+		return
+	}
+
+	if err := os.MkdirAll(utils.TusDir, 0o755); err != nil {
+		log.Printf("[DEBUG] ERRORS : %s", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "failed to create upload",
+		})
+		return
+	}
+
+	upload := &models.TusUpload{
+		ID:        uploadID,
+		UserID:    claims.UserID,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(utils.TusUploadTTL),
+	}
+
+	if err := h.repo.CreateTusUpload(ctx, upload); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Location", "/upload/"+upload.ID)
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusCreated)
+}
+
+// @Summary      Get upload status
+// @Description  Report how many bytes of a tus upload the server has received
+// @ID           tus-status
+// @Tags         upload
+// @Security     BearerAuth
+// @Param        id path string true "Upload ID"
+// @Param        Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success      200 "Upload-Offset and Upload-Length in the response headers"
+// @Failure      404 "Upload not found"
+// @Failure      410 "Upload expired"
+// @Router       /upload/{id} [head]
+func (h *UploadHandler) GetUploadStatus(ctx *gin.Context) {
+	upload, status := h.findUpload(ctx)
+	if upload == nil {
+		ctx.Status(status)
+		return
+	}
+
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Status(http.StatusOK)
+}
+
+// @Summary      Upload a chunk
+// @Description  Append data to a tus upload at the given offset, the upload can be attached to a post once complete
+// @ID           tus-patch
+// @Tags         upload
+// @Security     BearerAuth
+// @Accept       application/offset+octet-stream
+// @Param        id path string true "Upload ID"
+// @Param        Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param        Upload-Offset header int true "Offset the chunk starts at"
+// @Success      204 "New Upload-Offset in the response headers"
+// @Failure      404 {object} utils.ErrorResponse "Upload not found"
+// @Failure      409 {object} utils.ErrorResponse "Offset mismatch"
+// @Failure      410 {object} utils.ErrorResponse "Upload expired"
+// @Failure      415 {object} utils.ErrorResponse "Wrong content type"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /upload/{id} [patch]
+func (h *UploadHandler) PatchUpload(ctx *gin.Context) {
+	if ctx.ContentType() != "application/offset+octet-stream" {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"success": false,
+			"error":   "content type must be application/offset+octet-stream",
+		})
+		return
+	}
+
+	upload, status := h.findUpload(ctx)
+	if upload == nil {
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"error":   http.StatusText(status),
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset != upload.Offset || upload.CompletedAt != nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Upload-Offset does not match the current offset",
+		})
+		return
+	}
+
+	newOffset, err := h.repo.WriteTusChunk(ctx, upload.ID, offset, func() (int64, error) {
+		file, err := os.OpenFile(utils.TusFilePath(upload.ID), os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			return 0, err
+		}
+
+		/* Keep whatever arrived, even if the client dropped mid-chunk */
+		written, err := io.Copy(io.NewOffsetWriter(file, offset), io.LimitReader(ctx.Request.Body, upload.Length-offset))
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		return written, err
+	})
+	if err != nil {
+		switch err.Error() {
+		case "upload offset mismatch":
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Upload-Offset does not match the current offset",
+			})
+		case "upload is being written":
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "upload is being written by another request",
+			})
+		default:
+			log.Printf("[DEBUG] ERRORS : %s", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "failed to receive upload data",
+			})
+		}
+		return
+	}
+
+	if newOffset == upload.Length {
+		if err := h.completeUpload(ctx, upload); err != nil {
+			log.Printf("[DEBUG] ERRORS : %s", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "failed to store upload",
+			})
+			return
+		}
+	}
+
+	ctx.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary      Cancel an upload
+// @Description  Terminate a tus upload and discard its data
+// @ID           tus-delete
+// @Tags         upload
+// @Security     BearerAuth
+// @Param        id path string true "Upload ID"
+// @Param        Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success      204 "Upload terminated"
+// @Failure      404 {object} utils.ErrorResponse "Upload not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /upload/{id} [delete]
+func (h *UploadHandler) DeleteUpload(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	deleted, err := h.repo.DeleteTusUpload(ctx, ctx.Param("id"), claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "upload not found",
+		})
+		return
+	}
+
+	if err := os.Remove(utils.TusFilePath(ctx.Param("id"))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("Failed to remove upload data: ", err)
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+/* Look up the caller's upload, returning the status to answer with when it is unusable */
+func (h *UploadHandler) findUpload(ctx *gin.Context) (*models.TusUpload, int) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		return nil, http.StatusUnauthorized
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	upload, err := h.repo.GetTusUpload(ctx, ctx.Param("id"), claims.UserID)
+	if err != nil {
+		if err.Error() == "upload not found" {
+			return nil, http.StatusNotFound
+		}
+		log.Printf("[DEBUG] ERRORS : %s", err.Error())
+		return nil, http.StatusInternalServerError
+	}
+
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, http.StatusGone
+	}
+
+	return upload, http.StatusOK
+}
+
+/* Move a finished upload into the content addressed post storage */
+func (h *UploadHandler) completeUpload(ctx *gin.Context, upload *models.TusUpload) error {
+	ext := ""
+	if upload.Metadata != nil {
+		metadata, err := utils.ParseTusMetadata(*upload.Metadata)
+		if err == nil {
+			ext = filepath.Ext(metadata["filename"])
+		}
+	}
+
+	partPath := utils.TusFilePath(upload.ID)
+	src, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	stored, err := utils.StoreFile(ctx, src, ext, "public/post", "post", "post", h.repo.RegisterUpload)
+	if err != nil {
+		return err
+	}
+
+	if err := h.repo.CompleteTusUpload(ctx, upload.ID, stored.Path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(partPath); err != nil {
+		log.Println("Failed to remove upload data: ", err)
+	}
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
 	"github.com/febryanhernanda/social-media-apps/internal/repositories"
@@ -12,15 +15,20 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+/* Largest avatar file accepted before cropping */
+const maxAvatarSize = 5 << 20
+
 type UserHandler struct {
-	repo *repositories.UserRepository
-	rdb  *redis.Client
+	repo       *repositories.UserRepository
+	uploadRepo *repositories.UploadRepository
+	rdb        *redis.Client
 }
 
-func NewUserHandler(repo *repositories.UserRepository, rdb *redis.Client) *UserHandler {
+func NewUserHandler(repo *repositories.UserRepository, uploadRepo *repositories.UploadRepository, rdb *redis.Client) *UserHandler {
 	return &UserHandler{
-		repo: repo,
-		rdb:  rdb,
+		repo:       repo,
+		uploadRepo: uploadRepo,
+		rdb:        rdb,
 	}
 }
 
@@ -48,6 +56,278 @@ func (h *UserHandler) GetAllUser(ctx *gin.Context) {
 	})
 }
 
+// @Summary      Get a user profile
+// @Description  Get the profile of a user with follower, following and post counts, and whether you follow each other
+// @ID           get-user-profile
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "user ID"
+// @Success      200 {object} models.UserProfile
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/{id} [get]
+func (h *UserHandler) GetProfile(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	profile, err := h.repo.GetProfile(ctx, userID, claims.UserID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    profile,
+	})
+}
+
+/* ======================================================================= PROFILE EDITING */
+
+// @Summary      Update my profile
+// @Description  Change your name and biography, omitted fields are kept and an empty biography removes it
+// @ID           update-profile
+// @Tags         user
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body models.UpdateProfileRequest true "fields to change"
+// @Success      200 {object} models.UserProfile
+// @Failure      400 {object} utils.ErrorResponse "Bad request"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me [patch]
+func (h *UserHandler) UpdateProfile(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	var req models.UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "name cannot be empty",
+			})
+			return
+		}
+		req.Name = &name
+	}
+	if req.Biography != nil {
+		biography := strings.TrimSpace(*req.Biography)
+		req.Biography = &biography
+	}
+
+	if err := h.repo.UpdateProfile(ctx, claims.UserID, req.Name, req.Biography); err != nil {
+		h.profileError(ctx, err)
+		return
+	}
+
+	h.invalidateUserCache(ctx)
+
+	profile, err := h.repo.GetProfile(ctx, claims.UserID, claims.UserID)
+	if err != nil {
+		h.profileError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "profile updated",
+		"data":    profile,
+	})
+}
+
+// @Summary      Upload my avatar
+// @Description  Replace your avatar, the image is cropped to a centered square. Avatars are served publicly under /avatar
+// @ID           update-avatar
+// @Tags         user
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        avatar formData file true "jpeg, png or gif image, at most 5 MB"
+// @Success      200 {object} models.UserProfile
+// @Failure      400 {object} utils.ErrorResponse "Missing, unsupported or too large image"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/avatar [put]
+func (h *UserHandler) UpdateAvatar(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	file, err := ctx.FormFile("avatar")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "avatar image is required",
+		})
+		return
+	}
+	if file.Size > maxAvatarSize {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "avatar image must be at most 5 MB",
+		})
+		return
+	}
+
+	upload, err := utils.UploadSquareImage(ctx, "avatar", "public/avatar", "avatar", "avatar", h.uploadRepo.RegisterUpload)
+	if err != nil {
+		switch err.Error() {
+		case "unsupported image, use jpeg, png or gif", "image is too large":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			log.Printf("[DEBUG] ERRORS : %s", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to upload avatar",
+			})
+		}
+		return
+	}
+
+	if err := h.repo.SetAvatar(ctx, claims.UserID, upload.Path); err != nil {
+		h.profileError(ctx, err)
+		return
+	}
+
+	h.invalidateUserCache(ctx)
+
+	profile, err := h.repo.GetProfile(ctx, claims.UserID, claims.UserID)
+	if err != nil {
+		h.profileError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "avatar updated",
+		"data":    profile,
+	})
+}
+
+// @Summary      Remove my avatar
+// @Description  Remove your avatar
+// @ID           remove-avatar
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Avatar removed"
+// @Failure      400 {object} utils.ErrorResponse "No avatar to remove"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/avatar [delete]
+func (h *UserHandler) RemoveAvatar(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	if err := h.repo.RemoveAvatar(ctx, claims.UserID); err != nil {
+		if err.Error() == "no avatar to remove" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.invalidateUserCache(ctx)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "avatar removed",
+	})
+}
+
+func (h *UserHandler) profileError(ctx *gin.Context, err error) {
+	if err.Error() == "user not found" {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+/* Cached feeds embed the author name and avatar of every post */
+func (h *UserHandler) invalidateUserCache(ctx *gin.Context) {
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+}
+
 /* ======================================================================= NOTIFICATIONS */
 
 // @Summary      Get user notifications
@@ -160,15 +440,16 @@ func (h *UserHandler) ReadNotification(ctx *gin.Context) {
 /* ======================================================================= FOLLOWING */
 
 // @Summary      Follow a user
-// @Description  Send a follow request to another user
+// @Description  Follow another user. Following a private account sends a follow request that the user has to approve
 // @ID           follow-user
 // @Tags         user
 // @Security     BearerAuth
 // @Produce      json
 // @Param        id path int true "ID of the user to follow"
-// @Success 	 200 {object} map[string]interface{} "Successfully following the user"
-// @Failure      400 {object} utils.ErrorResponse "Invalid user ID / Cannot follow yourself / Already following"
+// @Success 	 200 {object} map[string]interface{} "Successfully following the user, or follow request sent"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID / Cannot follow yourself / Already following / Already requested"
 // @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
 // @Failure      500 {object} utils.ErrorResponse "Internal server error"
 // @Router       /user/{id}/follow [post]
 func (h *UserHandler) FollowRequest(ctx *gin.Context) {
@@ -198,6 +479,7 @@ func (h *UserHandler) FollowRequest(ctx *gin.Context) {
 			"success": false,
 			"error":   "cannot follow yourself",
 		})
+		return
 	}
 
 	followReq := models.Follows{
@@ -207,12 +489,19 @@ func (h *UserHandler) FollowRequest(ctx *gin.Context) {
 
 	follow, err := h.repo.FollowRequest(ctx, &followReq)
 	if err != nil {
-		if err.Error() == "already following this user" {
+		switch err.Error() {
+		case "already following this user", "follow already requested":
 			ctx.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
 				"error":   err.Error(),
 			})
 			return
+		case "user not found":
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -221,6 +510,15 @@ func (h *UserHandler) FollowRequest(ctx *gin.Context) {
 		return
 	}
 
+	if follow.Pending {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": fmt.Sprintf("Follow request sent to user with ID %d", follow.FollowedUserID),
+			"data":    follow,
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": fmt.Sprintf("You are now following user with ID %d", follow.FollowedUserID),
@@ -229,7 +527,7 @@ func (h *UserHandler) FollowRequest(ctx *gin.Context) {
 }
 
 // @Summary      Unfollow a user
-// @Description  Remove a follow relationship with another user
+// @Description  Remove a follow relationship with another user, or withdraw a pending follow request
 // @ID           unfollow-user
 // @Tags         user
 // @Security     BearerAuth
@@ -283,3 +581,712 @@ func (h *UserHandler) UnfollowRequest(ctx *gin.Context) {
 		"message": "unfollowed successfully",
 	})
 }
+
+/* Position in a followers or following list, encoded into the opaque cursor */
+type followCursor struct {
+	BeforeID int `json:"before_id"`
+}
+
+type followListFunc func(ctx context.Context, userID, viewerID int, beforeID *int, limit int) ([]models.FollowUser, *int, error)
+
+// @Summary      Get followers
+// @Description  List the users following a user, most recent first, with cursor pagination
+// @ID           get-followers
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "user ID"
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        limit query int false "Users per page" default(10)
+// @Success      200 {object} models.FollowUser
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID or cursor"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/{id}/followers [get]
+func (h *UserHandler) GetFollowers(ctx *gin.Context) {
+	h.listFollows(ctx, h.repo.GetFollowers)
+}
+
+// @Summary      Get following
+// @Description  List the users a user follows, most recent first, with cursor pagination
+// @ID           get-following
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "user ID"
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        limit query int false "Users per page" default(10)
+// @Success      200 {object} models.FollowUser
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID or cursor"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/{id}/following [get]
+func (h *UserHandler) GetFollowing(ctx *gin.Context) {
+	h.listFollows(ctx, h.repo.GetFollowing)
+}
+
+// @Summary      Get mutual followers
+// @Description  List the followers of a user that you follow too, most recent first, with cursor pagination
+// @ID           get-mutual-followers
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "user ID"
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        limit query int false "Users per page" default(10)
+// @Success      200 {object} models.FollowUser
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID or cursor"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/{id}/followers/mutual [get]
+func (h *UserHandler) GetMutualFollowers(ctx *gin.Context) {
+	h.listFollows(ctx, h.repo.GetMutualFollowers)
+}
+
+func (h *UserHandler) listFollows(ctx *gin.Context, list followListFunc) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	var beforeID *int
+	if raw := ctx.Query("cursor"); raw != "" {
+		var cursor followCursor
+		if err := utils.DecodeCursor(raw, &cursor); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		beforeID = &cursor.BeforeID
+	}
+
+	users, nextID, err := list(ctx, userID, claims.UserID, beforeID, utils.GetLimit(ctx))
+	if err != nil {
+		h.profileError(ctx, err)
+		return
+	}
+
+	var nextCursor *string
+	if nextID != nil {
+		cursor := utils.EncodeCursor(followCursor{BeforeID: *nextID})
+		nextCursor = &cursor
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        users,
+		"next_cursor": nextCursor,
+	})
+}
+
+/* ======================================================================= FOLLOW REQUESTS */
+
+// @Summary      Get follow requests
+// @Description  List the pending requests to follow your private account, newest first, with cursor pagination
+// @ID           get-follow-requests
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        limit query int false "Requests per page" default(10)
+// @Success      200 {object} models.PendingFollow
+// @Failure      400 {object} utils.ErrorResponse "Invalid cursor"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/follow-requests [get]
+func (h *UserHandler) GetFollowRequests(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	var beforeID *int
+	if raw := ctx.Query("cursor"); raw != "" {
+		var cursor followCursor
+		if err := utils.DecodeCursor(raw, &cursor); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		beforeID = &cursor.BeforeID
+	}
+
+	requests, nextID, err := h.repo.GetFollowRequests(ctx, claims.UserID, beforeID, utils.GetLimit(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var nextCursor *string
+	if nextID != nil {
+		cursor := utils.EncodeCursor(followCursor{BeforeID: *nextID})
+		nextCursor = &cursor
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        requests,
+		"next_cursor": nextCursor,
+	})
+}
+
+// @Summary      Approve a follow request
+// @Description  Let a user who asked to follow you become a follower
+// @ID           approve-follow-request
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of the user who sent the request"
+// @Success      200 {object} map[string]interface{} "Follow request approved"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Follow request not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/follow-requests/{id}/approve [post]
+func (h *UserHandler) ApproveFollowRequest(ctx *gin.Context) {
+	h.answerFollowRequest(ctx, h.repo.ApproveFollowRequest, "follow request approved")
+}
+
+// @Summary      Reject a follow request
+// @Description  Decline a request to follow you, the user is not notified
+// @ID           reject-follow-request
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of the user who sent the request"
+// @Success      200 {object} map[string]interface{} "Follow request rejected"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "Follow request not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/follow-requests/{id} [delete]
+func (h *UserHandler) RejectFollowRequest(ctx *gin.Context) {
+	h.answerFollowRequest(ctx, h.repo.RejectFollowRequest, "follow request rejected")
+}
+
+func (h *UserHandler) answerFollowRequest(ctx *gin.Context, answer func(ctx context.Context, userID, requesterID int) error, message string) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	requesterID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	if err := answer(ctx, claims.UserID, requesterID); err != nil {
+		if err.Error() == "follow request not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	/* An approved requester now sees the posts in their feed */
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", requesterID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
+
+/* ======================================================================= CLOSE FRIENDS */
+
+// @Summary      Get close friends
+// @Description  List the users who can see your close friends posts
+// @ID           get-close-friends
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.UserSummary
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/close-friends [get]
+func (h *UserHandler) GetCloseFriends(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	friends, err := h.repo.GetCloseFriends(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    friends,
+	})
+}
+
+// @Summary      Add a close friend
+// @Description  Let a user see your close friends posts
+// @ID           add-close-friend
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of the user to add"
+// @Success      200 {object} map[string]interface{} "Close friend added"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID / Already a close friend"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/close-friends/{id} [post]
+func (h *UserHandler) AddCloseFriend(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	friendID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	if friendID == claims.UserID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "cannot add yourself as a close friend",
+		})
+		return
+	}
+
+	if err := h.repo.AddCloseFriend(ctx, claims.UserID, friendID); err != nil {
+		switch err.Error() {
+		case "already a close friend":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		case "user not found":
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	/* The friend's cached feed does not include our close friends posts yet */
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", friendID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("user with ID %d added to close friends", friendID),
+	})
+}
+
+// @Summary      Remove a close friend
+// @Description  Stop a user from seeing your close friends posts
+// @ID           remove-close-friend
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of the user to remove"
+// @Success      200 {object} map[string]interface{} "Close friend removed"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID / Not a close friend"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/close-friends/{id} [delete]
+func (h *UserHandler) RemoveCloseFriend(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	friendID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	if err := h.repo.RemoveCloseFriend(ctx, claims.UserID, friendID); err != nil {
+		if err.Error() == "not a close friend" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := utils.InvalidateCache(ctx, h.rdb, []string{fmt.Sprintf("feed:post:%d", friendID)}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("user with ID %d removed from close friends", friendID),
+	})
+}
+
+/* ======================================================================= BLOCKING */
+
+// @Summary      Get blocked users
+// @Description  List the users you blocked, most recent first
+// @ID           get-blocked-users
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.UserSummary
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/blocks [get]
+func (h *UserHandler) GetBlockedUsers(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	users, err := h.repo.GetBlockedUsers(ctx, claims.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    users,
+	})
+}
+
+// @Summary      Block a user
+// @Description  Block a user. Follows in both directions are removed, and neither of you can follow, react to, comment on, mention or see the other's posts and profile
+// @ID           block-user
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of the user to block"
+// @Success      200 {object} map[string]interface{} "User blocked"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID / Cannot block yourself / Already blocked"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/{id}/block [post]
+func (h *UserHandler) BlockUser(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	blockedID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	if blockedID == claims.UserID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "cannot block yourself",
+		})
+		return
+	}
+
+	if err := h.repo.Block(ctx, claims.UserID, blockedID); err != nil {
+		switch err.Error() {
+		case "already blocked":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		case "user not found":
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	/* Both cached feeds may hold posts of the other user */
+	h.invalidateBlockCache(ctx, claims.UserID, blockedID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("user with ID %d blocked", blockedID),
+	})
+}
+
+// @Summary      Unblock a user
+// @Description  Lift a block. Follows removed by the block are not restored
+// @ID           unblock-user
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "ID of the user to unblock"
+// @Success      200 {object} map[string]interface{} "User unblocked"
+// @Failure      400 {object} utils.ErrorResponse "Invalid user ID / Not blocked"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/{id}/block [delete]
+func (h *UserHandler) UnblockUser(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	blockedID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+		return
+	}
+
+	if err := h.repo.Unblock(ctx, claims.UserID, blockedID); err != nil {
+		if err.Error() == "not blocked" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.invalidateBlockCache(ctx, claims.UserID, blockedID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("user with ID %d unblocked", blockedID),
+	})
+}
+
+func (h *UserHandler) invalidateBlockCache(ctx *gin.Context, userID, blockedID int) {
+	prefixes := []string{fmt.Sprintf("feed:post:%d", userID), fmt.Sprintf("feed:post:%d", blockedID)}
+	if err := utils.InvalidateCache(ctx, h.rdb, prefixes); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+}
+
+/* ======================================================================= SETTINGS */
+
+// @Summary      Get settings
+// @Description  Get your preferences. sensitive_content tells whether posts with a content warning or sensitive media are hidden, blurred or shown, is_private whether following you needs your approval
+// @ID           get-settings
+// @Tags         user
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.UserSettings
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/settings [get]
+func (h *UserHandler) GetSettings(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	settings, err := h.repo.GetSettings(ctx, claims.UserID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// @Summary      Update settings
+// @Description  Change your preferences, omitted fields are kept. sensitive_content is one of hide, blur or show.
+// @Description  A private account only shows its posts to followers, turning it public approves every pending follow request
+// @ID           update-settings
+// @Tags         user
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body models.UpdateSettingsRequest true "settings to change"
+// @Success      200 {object} models.UserSettings
+// @Failure      400 {object} utils.ErrorResponse "Bad request"
+// @Failure      401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure      404 {object} utils.ErrorResponse "User not found"
+// @Failure      500 {object} utils.ErrorResponse "Internal server error"
+// @Router       /user/me/settings [patch]
+func (h *UserHandler) UpdateSettings(ctx *gin.Context) {
+	rawClaims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "unauthorized",
+		})
+		return
+	}
+	claims := rawClaims.(*utils.Claims)
+
+	var req models.UpdateSettingsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.repo.UpdateSettings(ctx, claims.UserID, &req)
+	if err != nil {
+		if err.Error() == "user not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	/* The cached feed was filtered and blurred with the previous settings, and going private hides posts from other feeds */
+	prefixes := []string{fmt.Sprintf("feed:post:%d", claims.UserID)}
+	if req.IsPrivate != nil {
+		prefixes = []string{"feed:post:"}
+	}
+	if err := utils.InvalidateCache(ctx, h.rdb, prefixes); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "settings updated",
+		"data":    settings,
+	})
+}
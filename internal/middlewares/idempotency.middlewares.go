@@ -0,0 +1,314 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	idempotencyTTL       = 24 * time.Hour
+	maxIdempotencyKey    = 255
+
+	/* Large enough for a post with an image */
+	maxIdempotentBody = utils.TusMaxSize + 1<<20
+)
+
+/*
+Replay the first response of a request sent again with the same Idempotency-Key header, per user, for 24 hours.
+Keys live in Redis and in Postgres while Redis is unavailable. Reusing a key for a different request is rejected,
+and so is a retry while the first request is still being handled. Responses with a 5xx status are not kept,
+the request can be retried with the same key. Requests without the header are handled as usual.
+Must run after VerifyToken.
+*/
+func Idempotency(repo *repositories.IdempotencyRepository, rdb *redis.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKey),
+			})
+			return
+		}
+
+		rawClaims, exists := ctx.Get("claims")
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "unauthorized",
+			})
+			return
+		}
+		userID := rawClaims.(*utils.Claims).UserID
+
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "failed to read request body",
+			})
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"success": false,
+				"error":   "request body too large",
+			})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		store := &idempotencyStore{repo: repo, rdb: rdb, userID: userID, key: key}
+		requestHash := fingerprintRequest(ctx.Request, body)
+
+		previous, err := store.reserve(ctx, requestHash)
+		if err != nil {
+			log.Println("Idempotency error: ", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Server error",
+			})
+			return
+		}
+
+		if previous != nil {
+			switch {
+			case previous.RequestHash != requestHash:
+				ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"success": false,
+					"error":   fmt.Sprintf("%s was already used for a different request", IdempotencyKeyHeader),
+				})
+			case previous.StatusCode == 0:
+				ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"success": false,
+					"error":   fmt.Sprintf("a request with this %s is still being processed", IdempotencyKeyHeader),
+				})
+			default:
+				ctx.Header("Idempotent-Replayed", "true")
+				ctx.Data(previous.StatusCode, previous.ContentType, previous.Body)
+				ctx.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		completed := false
+		defer func() {
+			/* Also runs when the handler panics */
+			if !completed {
+				if err := store.release(context.Background()); err != nil {
+					log.Println("Idempotency release error: ", err)
+				}
+			}
+		}()
+
+		ctx.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record := &models.IdempotencyRecord{
+			RequestHash: requestHash,
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}
+		if err := store.complete(context.Background(), record); err != nil {
+			log.Println("Idempotency save error: ", err)
+			return
+		}
+		completed = true
+	}
+}
+
+/* Copies the response body while writing it */
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+/*
+Hash of the method, path and body. Multipart bodies are hashed part by part,
+clients pick a new boundary on every retry.
+*/
+func fingerprintRequest(req *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.Path)
+
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || !hashMultipart(h, body, params["boundary"]) {
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashMultipart(h hash.Hash, body []byte, boundary string) bool {
+	if boundary == "" {
+		return false
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+
+		fmt.Fprintf(h, "%q %q\n", part.FormName(), part.FileName())
+		if _, err := io.Copy(h, part); err != nil {
+			return false
+		}
+		h.Write([]byte{0})
+	}
+}
+
+/* Keys of one user in Redis, falling back to Postgres when Redis fails */
+type idempotencyStore struct {
+	repo     *repositories.IdempotencyRepository
+	rdb      *redis.Client
+	userID   int
+	key      string
+	inRedis  bool
+	reserved bool
+}
+
+func (s *idempotencyStore) redisKey() string {
+	return fmt.Sprintf("idempotency:%d:%s", s.userID, s.key)
+}
+
+func (s *idempotencyStore) reserve(ctx context.Context, requestHash string) (*models.IdempotencyRecord, error) {
+	if s.rdb != nil {
+		record, err := s.reserveRedis(ctx, requestHash)
+		if err == nil {
+			s.inRedis = true
+			if record != nil {
+				return record, nil
+			}
+			return s.reservedInDB(ctx)
+		}
+		log.Println("Redis idempotency error, back to DB: ", err)
+	}
+
+	record, err := s.repo.Reserve(ctx, s.userID, s.key, requestHash, idempotencyTTL)
+	if err != nil {
+		return nil, err
+	}
+	s.reserved = record == nil
+	return record, nil
+}
+
+func (s *idempotencyStore) reserveRedis(ctx context.Context, requestHash string) (*models.IdempotencyRecord, error) {
+	pending, err := json.Marshal(models.IdempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return nil, err
+	}
+
+	/* Retry once when the key expires between SETNX and GET */
+	for range 2 {
+		ok, err := s.rdb.SetNX(ctx, s.redisKey(), pending, idempotencyTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return nil, nil
+		}
+
+		raw, err := s.rdb.Get(ctx, s.redisKey()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record models.IdempotencyRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, err
+		}
+		return &record, nil
+	}
+
+	return nil, fmt.Errorf("idempotency key %q keeps expiring", s.key)
+}
+
+/*
+A key new to Redis may have been used while Redis was unavailable, Postgres is checked before the request
+is handled. The Redis reservation is dropped again when Postgres knows the key or cannot be asked.
+*/
+func (s *idempotencyStore) reservedInDB(ctx context.Context) (*models.IdempotencyRecord, error) {
+	record, err := s.repo.Find(ctx, s.userID, s.key)
+	if err == nil && record == nil {
+		s.reserved = true
+		return nil, nil
+	}
+
+	if delErr := s.rdb.Del(ctx, s.redisKey()).Err(); delErr != nil {
+		log.Println("Redis idempotency release error: ", delErr)
+	}
+	return record, err
+}
+
+func (s *idempotencyStore) complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	if !s.reserved {
+		return nil
+	}
+	if !s.inRedis {
+		return s.repo.Complete(ctx, s.userID, s.key, record)
+	}
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.rdb.SetArgs(ctx, s.redisKey(), value, redis.SetArgs{KeepTTL: true}).Err()
+}
+
+func (s *idempotencyStore) release(ctx context.Context) error {
+	if !s.reserved {
+		return nil
+	}
+	if !s.inRedis {
+		return s.repo.Release(ctx, s.userID, s.key)
+	}
+	return s.rdb.Del(ctx, s.redisKey()).Err()
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+/* Check the tus protocol version on every request except OPTIONS */
+func TusResumable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Tus-Resumable", utils.TusVersion)
+
+		if ctx.Request.Method != http.MethodOptions && ctx.GetHeader("Tus-Resumable") != utils.TusVersion {
+			ctx.Header("Tus-Version", utils.TusVersion)
+			ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{
+				"success": false,
+				"error":   "unsupported tus version",
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -4,6 +4,7 @@ type RegisterUser struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 	Name     string `json:"name" binding:"required"`
+	Username string `json:"username" binding:"omitempty,min=3,max=30"`
 }
 
 type LoginUser struct {
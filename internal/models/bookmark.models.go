@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Bookmark struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	PostID       int       `json:"post_id"`
+	CollectionID *int      `json:"collection_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type BookmarkRequest struct {
+	CollectionID *int `json:"collection_id" form:"collection_id"`
+}
+
+type BookmarkCollection struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	BookmarkCount int       `json:"bookmark_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CollectionRequest struct {
+	Name string `json:"name" form:"name" binding:"required,max=100"`
+}
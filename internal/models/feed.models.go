@@ -3,14 +3,49 @@ package models
 import "time"
 
 type FeedPost struct {
-	ID           int       `json:"id"`
-	Content      string    `json:"content"`
-	ImagePath    *string   `json:"image_path,omitempty"`
-	AuthorID     int       `json:"author_id"`
-	AuthorName   string    `json:"author_name"`
-	AvatarPath   *string   `json:"author_avatar,omitempty"`
-	CreatedAt    time.Time `json:"-"`
-	CreatedAtStr string    `json:"created_at"`
-	LikeCount    int       `json:"like_count"`
-	Comments     []Comment `json:"comments"`
+	ID             int            `json:"id"`
+	Content        string         `json:"content"`
+	ContentHTML    string         `json:"content_html"`
+	ImagePath      *string        `json:"image_path,omitempty"`
+	AuthorID       int            `json:"author_id"`
+	AuthorName     string         `json:"author_name"`
+	AvatarPath     *string        `json:"author_avatar,omitempty"`
+	CreatedAt      time.Time      `json:"-"`
+	CreatedAtStr   string         `json:"created_at"`
+	LikeCount      int            `json:"like_count"`
+	ReactionCounts map[string]int `json:"reaction_counts"`
+	MyReaction     *string        `json:"my_reaction"`
+	Comments       []Comment      `json:"comments"`
+	Hashtags       []string       `json:"hashtags"`
+	Mentions       []Mention      `json:"mentions"`
+	PostType       string         `json:"type"`
+	Visibility     string         `json:"visibility"`
+	RepostCount    int            `json:"repost_count"`
+	QuoteCount     int            `json:"quote_count"`
+	RepostedBy     *UserSummary   `json:"reposted_by,omitempty"`
+	QuotedPost     *QuotedPost    `json:"quoted_post,omitempty"`
+	BookmarkedByMe bool           `json:"bookmarked_by_me"`
+	Poll           *Poll          `json:"poll,omitempty"`
+	LinkPreview    *LinkPreview   `json:"link_preview,omitempty"`
+	IsPinned       bool           `json:"is_pinned"`
+	ContentWarning *string        `json:"content_warning,omitempty"`
+	SensitiveMedia bool           `json:"sensitive_media"`
+	Blurred        bool           `json:"blurred"`
+	ThreadRootID   *int           `json:"thread_root_id,omitempty"`
+	ThreadCount    int            `json:"thread_count"`
+}
+
+/* Post embedded in a quote */
+type QuotedPost struct {
+	ID           int     `json:"id"`
+	Content      string  `json:"content"`
+	ContentHTML  string  `json:"content_html"`
+	ImagePath    *string `json:"image_path,omitempty"`
+	AuthorID     int     `json:"author_id"`
+	AuthorName   string  `json:"author_name"`
+	AvatarPath   *string `json:"author_avatar,omitempty"`
+	CreatedAtStr string  `json:"created_at"`
+
+	ContentWarning *string `json:"content_warning,omitempty"`
+	SensitiveMedia bool    `json:"sensitive_media"`
 }
@@ -0,0 +1,9 @@
+package models
+
+/* First response to a request sent with an Idempotency-Key, StatusCode is 0 while it is being handled */
+type IdempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
@@ -0,0 +1,19 @@
+package models
+
+type PostInsights struct {
+	PostID        int          `json:"post_id"`
+	Views         int          `json:"views"`
+	UniqueViewers int          `json:"unique_viewers"`
+	LikeCount     int          `json:"like_count"`
+	CommentCount  int          `json:"comment_count"`
+	Timeline      []InsightDay `json:"timeline"`
+}
+
+/* Activity of a post on a single day */
+type InsightDay struct {
+	Day           string `json:"day"`
+	Views         int    `json:"views"`
+	UniqueViewers int    `json:"unique_viewers"`
+	Likes         int    `json:"likes"`
+	Comments      int    `json:"comments"`
+}
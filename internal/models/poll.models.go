@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Poll struct {
+	ID             int          `json:"id"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	ClosesAt       time.Time    `json:"-"`
+	ClosesAtStr    string       `json:"closes_at"`
+	IsClosed       bool         `json:"is_closed"`
+	TotalVoters    int          `json:"total_voters"`
+	Options        []PollOption `json:"options"`
+	MyVotes        []int        `json:"my_votes"`
+}
+
+type PollOption struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Votes int    `json:"votes"`
+}
+
+type VoteRequest struct {
+	OptionIDs []int `json:"option_ids" binding:"required,min=1,max=4"`
+}
@@ -6,35 +6,128 @@ import (
 )
 
 type Post struct {
+	ID             int          `json:"id"`
+	Content        string       `json:"content"`
+	ContentHTML    string       `json:"content_html"`
+	ImagePath      *string      `json:"image_path,omitempty"`
+	UserID         int          `json:"user_id"`
+	PostType       string       `json:"type"`
+	OriginalPostID *int         `json:"original_post_id,omitempty"`
+	Visibility     string       `json:"visibility"`
+	Status         string       `json:"status"`
+	PublishAt      *time.Time   `json:"publish_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Hashtags       []string     `json:"hashtags"`
+	Mentions       []Mention    `json:"mentions"`
+	Poll           *Poll        `json:"poll,omitempty"`
+	LinkURL        *string      `json:"link_url,omitempty"`
+	LinkPreview    *LinkPreview `json:"link_preview,omitempty"`
+	ContentWarning *string      `json:"content_warning,omitempty"`
+	SensitiveMedia bool         `json:"sensitive_media"`
+	ThreadRootID   *int         `json:"thread_root_id,omitempty"`
+	PreviousPostID *int         `json:"previous_post_id,omitempty"`
+}
+
+type CreatePostRequest struct {
+	Content    string                `form:"content" binding:"required"`
+	Image      *multipart.FileHeader `form:"image"`
+	UploadID   string                `form:"upload_id"`
+	Visibility string                `form:"visibility" binding:"omitempty,oneof=public followers close_friends only_me"`
+	Status     string                `form:"status" binding:"omitempty,oneof=draft published"`
+	PublishAt  *time.Time            `form:"publish_at" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	PollOptions  []string   `form:"poll_options" binding:"omitempty,min=2,max=4,dive,required,max=100"`
+	PollClosesAt *time.Time `form:"poll_closes_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	PollMultiple bool       `form:"poll_multiple"`
+
+	ContentWarning string `form:"content_warning" binding:"omitempty,max=200"`
+	SensitiveMedia bool   `form:"sensitive_media"`
+
+	PreviousPostID *int `form:"previous_post_id"`
+}
+
+type UpdatePostRequest struct {
+	Content string `json:"content" form:"content" binding:"required"`
+}
+
+type SchedulePostRequest struct {
+	PublishAt time.Time `json:"publish_at" binding:"required"`
+}
+
+type QuotePostRequest struct {
+	Content string `json:"content" form:"content" binding:"required"`
+}
+
+type Reaction struct {
 	ID        int       `json:"id"`
-	Content   string    `json:"content"`
-	ImagePath *string   `json:"image_path,omitempty"`
+	PostID    int       `json:"post_id"`
 	UserID    int       `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
+	Type      string    `json:"type"`
+	ReactedAt time.Time `json:"reacted_at"`
 }
 
-type CreatePostRequest struct {
-	Content string                `form:"content" binding:"required"`
-	Image   *multipart.FileHeader `form:"image"`
+/* Like state returned by the like endpoints */
+type LikeState struct {
+	PostID    int  `json:"post_id"`
+	Liked     bool `json:"liked"`
+	LikeCount int  `json:"like_count"`
+}
+
+type ReactionRequest struct {
+	Type string `json:"type" form:"type" binding:"required"`
+}
+
+/* A user who reacted to a post */
+type ReactionUser struct {
+	UserSummary
+	Type      string    `json:"type"`
+	ReactedAt time.Time `json:"reacted_at"`
 }
 
-type Like struct {
-	ID      int       `json:"id"`
-	PostID  int       `json:"post_id"`
-	UserID  int       `json:"user_id"`
-	LikedAt time.Time `json:"liked_at"`
+/* A user who liked a post, FollowedByMe tells whether the viewer follows them */
+type LikeUser struct {
+	UserSummary
+	FollowedByMe bool      `json:"followed_by_me"`
+	LikedAt      time.Time `json:"liked_at"`
+	ReactionID   int       `json:"-"`
+}
+
+/* Position in the list of likes, encoded into the opaque cursor */
+type LikesCursor struct {
+	Followed bool      `json:"followed"`
+	LikedAt  time.Time `json:"liked_at"`
+	ID       int       `json:"id"`
 }
 
 type Comment struct {
 	ID           int       `json:"id"`
 	Content      string    `json:"content"`
+	ContentHTML  string    `json:"content_html"`
 	Name         *string   `json:"name,omitempty"`
 	PostID       int       `json:"post_id"`
 	UserID       int       `json:"user_id"`
 	CreatedAt    time.Time `json:"-"`
 	CreatedAtStr string    `json:"created_at"`
+	Mentions     []Mention `json:"mentions"`
 }
 
 type CommentRequest struct {
 	Content string `json:"content" form:"content" binding:"required"`
 }
+
+/* Preview card of the first link in a post, fetched in the background */
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+/* A mentioned user, Start and End are character offsets of "@username" in the content */
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
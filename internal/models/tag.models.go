@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type Hashtag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Upload struct {
+	ID         int       `json:"id"`
+	Path       string    `json:"path"`
+	Hash       string    `json:"hash"`
+	Size       int64     `json:"size"`
+	RefCount   int       `json:"ref_count"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type TusUpload struct {
+	ID          string     `json:"id"`
+	UserID      int        `json:"user_id"`
+	Length      int64      `json:"upload_length"`
+	Offset      int64      `json:"upload_offset"`
+	Metadata    *string    `json:"metadata,omitempty"`
+	FilePath    *string    `json:"file_path,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -7,6 +7,7 @@ type User struct {
 	Email      string    `form:"email"`
 	Password   string    `form:"password"`
 	Name       string    `form:"name"`
+	Username   *string   `form:"username"`
 	AvatarPath *string   `form:"avatar_path,omitempty"`
 	Biography  *string   `form:"biography,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -16,16 +17,65 @@ type AllUser struct {
 	ID         int       `form:"id"`
 	Email      string    `form:"email"`
 	Name       string    `form:"name"`
+	Username   *string   `form:"username"`
 	AvatarPath *string   `form:"avatar_path,omitempty"`
 	Biography  *string   `form:"biography,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+type UserSummary struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	Username   *string `json:"username"`
+	AvatarPath *string `json:"avatar_path,omitempty"`
+}
+
+/* Profile of a user as seen by the viewer */
+type UserProfile struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Username       *string   `json:"username"`
+	AvatarPath     *string   `json:"avatar_path,omitempty"`
+	Biography      *string   `json:"biography,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	FollowerCount  int       `json:"follower_count"`
+	FollowingCount int       `json:"following_count"`
+	PostCount      int       `json:"post_count"`
+	IsPrivate      bool      `json:"is_private"`
+	IsFollowing    bool      `json:"is_following"`
+	FollowsYou     bool      `json:"follows_you"`
+	Requested      bool      `json:"follow_requested"`
+}
+
+/* Only the provided fields are changed, an empty biography removes it */
+type UpdateProfileRequest struct {
+	Name      *string `json:"name" binding:"omitempty,max=50"`
+	Biography *string `json:"biography" binding:"omitempty,max=160"`
+}
+
+/* Pending is set when the followed account is private and the follow waits for approval */
 type Follows struct {
 	ID             int       `json:"id"`
 	UserID         int       `json:"user_id"`
 	FollowedUserID int       `json:"followed_user_id"`
 	CreatedAt      time.Time `json:"created_at"`
+	Pending        bool      `json:"pending"`
+}
+
+/* Request to follow a private account */
+type PendingFollow struct {
+	RequestID int `json:"request_id"`
+	UserSummary
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+/* A user in a followers or following list, with the follow state relative to the viewer */
+type FollowUser struct {
+	UserSummary
+	IsFollowing bool      `json:"is_following"`
+	FollowsYou  bool      `json:"follows_you"`
+	FollowedAt  time.Time `json:"followed_at"`
+	FollowID    int       `json:"-"`
 }
 
 type CreateFollowRequest struct {
@@ -42,3 +92,15 @@ type Notifications struct {
 	IsRead      bool      `json:"is_read"`
 	CreatedAt   time.Time `json:"created_at"`
 }
+
+/* Preferences of the signed in user */
+type UserSettings struct {
+	SensitiveContent string `json:"sensitive_content"`
+	IsPrivate        bool   `json:"is_private"`
+}
+
+/* Only the provided settings are changed */
+type UpdateSettingsRequest struct {
+	SensitiveContent *string `json:"sensitive_content" binding:"omitempty,oneof=hide blur show"`
+	IsPrivate        *bool   `json:"is_private"`
+}
@@ -2,8 +2,12 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,22 +22,88 @@ func NewAuthRepository(db *pgxpool.Pool) *AuthRepository {
 }
 
 func (r *AuthRepository) RegisterUser(ctx context.Context, req *models.RegisterUser) (*models.User, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
 	query := `
-		INSERT INTO users (email, password, name)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at
+		INSERT INTO users (email, password, name, username)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, username, created_at
 	`
 
 	var user models.User
-	err := r.DB.QueryRow(ctx, query, req.Email, req.Password, req.Name).
-		Scan(&user.ID, &user.CreatedAt)
+	err = dbTx.QueryRow(ctx, query, req.Email, req.Password, req.Name, req.Username).
+		Scan(&user.ID, &user.Username, &user.CreatedAt)
 	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("username already taken")
+		}
 		return nil, err
 	}
 
+	/* No username chosen, derive one from the email and keep it unique with the id */
+	if user.Username == nil {
+		user.Username, err = setDerivedUsername(ctx, dbTx, user.ID, utils.UsernameFromEmail(req.Email))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &user, nil
 }
 
+const (
+	/* Attempts at a derived username before giving up, each one after the first adds a counter */
+	maxUsernameAttempts = 5
+
+	/* Size of users.username */
+	maxUsernameLength = 30
+)
+
+/*
+Set base_<id> as the username, which someone may have picked already when registering.
+Taken names are retried as base_<id>_2, base_<id>_3, ..., shortening base to fit the column.
+*/
+func setDerivedUsername(ctx context.Context, dbTx pgx.Tx, userID int, base string) (*string, error) {
+	for attempt := 1; attempt <= maxUsernameAttempts; attempt++ {
+		suffix := fmt.Sprintf("_%d", userID)
+		if attempt > 1 {
+			suffix += fmt.Sprintf("_%d", attempt)
+		}
+		if len(base)+len(suffix) > maxUsernameLength {
+			base = base[:max(0, maxUsernameLength-len(suffix))]
+		}
+		username := base + suffix
+
+		/* A savepoint keeps the transaction usable after a unique violation */
+		savepoint, err := dbTx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set username: %w", err)
+		}
+		_, err = savepoint.Exec(ctx, "UPDATE users SET username = $2 WHERE id = $1", userID, username)
+		if err == nil {
+			if err := savepoint.Commit(ctx); err != nil {
+				return nil, fmt.Errorf("failed to set username: %w", err)
+			}
+			return &username, nil
+		}
+		savepoint.Rollback(ctx)
+
+		if pgErr, ok := err.(*pgconn.PgError); !ok || pgErr.Code != "23505" {
+			return nil, fmt.Errorf("failed to set username: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to set username: no free username for %q", base)
+}
+
 func (r *AuthRepository) LoginUser(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
 	query := `SELECT id, email, password FROM users WHERE email=$1`
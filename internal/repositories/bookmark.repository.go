@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BookmarkRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewBookmarkRepository(db *pgxpool.Pool) *BookmarkRepository {
+	return &BookmarkRepository{
+		DB: db,
+	}
+}
+
+/* Bookmark a post, or move an existing bookmark to another collection */
+func (r *BookmarkRepository) AddBookmark(ctx context.Context, userID, postID int, collectionID *int) (*models.Bookmark, error) {
+	if collectionID != nil {
+		var owned bool
+		query := "SELECT EXISTS (SELECT 1 FROM bookmark_collections WHERE id = $1 AND user_id = $2)"
+		if err := r.DB.QueryRow(ctx, query, *collectionID, userID).Scan(&owned); err != nil {
+			return nil, err
+		}
+		if !owned {
+			return nil, fmt.Errorf("collection not found")
+		}
+	}
+
+	/* Bookmarking a repost bookmarks the post it reposted, which is what lists show */
+	query := `
+		INSERT INTO bookmarks (user_id, post_id, collection_id)
+		SELECT $1, o.id, $3
+		FROM posts p
+		JOIN posts o ON o.id = CASE WHEN p.post_type = 'repost' THEN p.original_post_id ELSE p.id END
+		WHERE p.id = $2 AND p.deleted_at IS NULL AND o.deleted_at IS NULL
+		  AND ` + visiblePostCondition("p", "$1") + `
+		  AND ` + visiblePostCondition("o", "$1") + `
+		ON CONFLICT (user_id, post_id) DO UPDATE SET collection_id = EXCLUDED.collection_id
+		RETURNING id, user_id, post_id, collection_id, created_at
+	`
+
+	var bookmark models.Bookmark
+	err := r.DB.QueryRow(ctx, query, userID, postID, collectionID).
+		Scan(&bookmark.ID, &bookmark.UserID, &bookmark.PostID, &bookmark.CollectionID, &bookmark.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("post not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &bookmark, nil
+}
+
+func (r *BookmarkRepository) RemoveBookmark(ctx context.Context, userID, postID int) error {
+	query := `
+		DELETE FROM bookmarks
+		WHERE user_id = $1 AND post_id = (
+			SELECT CASE WHEN p.post_type = 'repost' THEN p.original_post_id ELSE p.id END
+			FROM posts p
+			WHERE p.id = $2
+		)
+	`
+	res, err := r.DB.Exec(ctx, query, userID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bookmark: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("bookmark not found")
+	}
+
+	return nil
+}
+
+/* Bookmarked posts newest first, older than beforeID when set; also returns the cursor ID for the next page */
+func (r *BookmarkRepository) GetBookmarks(ctx context.Context, userID int, collectionID *int, beforeID *int, limit int) ([]models.FeedPost, *int, error) {
+	query := `
+		SELECT id, post_id
+		FROM bookmarks
+		WHERE user_id = $1
+		  AND ($2::int4 IS NULL OR collection_id = $2)
+		  AND ($3::int4 IS NULL OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID, collectionID, beforeID, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bookmarkIDs, postIDs []int
+	for rows.Next() {
+		var bookmarkID, postID int
+		if err := rows.Scan(&bookmarkID, &postID); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		bookmarkIDs = append(bookmarkIDs, bookmarkID)
+		postIDs = append(postIDs, postID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var nextID *int
+	if len(postIDs) > limit {
+		nextID = &bookmarkIDs[limit-1]
+		postIDs = postIDs[:limit]
+	}
+
+	if len(postIDs) == 0 {
+		return []models.FeedPost{}, nil, nil
+	}
+
+	postRows, err := r.DB.Query(ctx, feedPostSelect+`
+    WHERE p.id = ANY($2) AND p.deleted_at IS NULL
+    `, userID, postIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := scanFeedPosts(postRows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	/* Keep the bookmark order, posts deleted since bookmarking drop out */
+	byID := make(map[int]models.FeedPost, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	ordered := make([]models.FeedPost, 0, len(posts))
+	for _, postID := range postIDs {
+		if post, ok := byID[postID]; ok {
+			ordered = append(ordered, post)
+		}
+	}
+
+	return ordered, nextID, nil
+}
+
+/* ===================================================================================================================== COLLECTIONS */
+func (r *BookmarkRepository) GetCollections(ctx context.Context, userID int) ([]models.BookmarkCollection, error) {
+	query := `
+		SELECT bc.id, bc.name, COUNT(b.id) AS bookmark_count, bc.created_at
+		FROM bookmark_collections bc
+		LEFT JOIN bookmarks b ON b.collection_id = bc.id
+		WHERE bc.user_id = $1
+		GROUP BY bc.id
+		ORDER BY bc.name
+	`
+
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collections []models.BookmarkCollection
+	for rows.Next() {
+		var c models.BookmarkCollection
+		if err := rows.Scan(&c.ID, &c.Name, &c.BookmarkCount, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+
+	return collections, rows.Err()
+}
+
+func (r *BookmarkRepository) CreateCollection(ctx context.Context, userID int, name string) (*models.BookmarkCollection, error) {
+	query := `
+		INSERT INTO bookmark_collections (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, name, created_at
+	`
+
+	var collection models.BookmarkCollection
+	err := r.DB.QueryRow(ctx, query, userID, name).Scan(&collection.ID, &collection.Name, &collection.CreatedAt)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("collection already exists")
+		}
+		return nil, err
+	}
+
+	return &collection, nil
+}
+
+/* Deleting a collection keeps its bookmarks, they just lose the collection */
+func (r *BookmarkRepository) DeleteCollection(ctx context.Context, userID, collectionID int) error {
+	res, err := r.DB.Exec(ctx, "DELETE FROM bookmark_collections WHERE id = $1 AND user_id = $2", collectionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("collection not found")
+	}
+
+	return nil
+}
@@ -3,8 +3,11 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,59 +21,194 @@ func NewFeedRepository(db *pgxpool.Pool) *FeedRepository {
 	}
 }
 
-func (r *FeedRepository) GetUserFeed(ctx context.Context, userID int) ([]models.FeedPost, error) {
-	query := `
-    SELECT 
-        p.id AS post_id,
-        p.content,
-        p.image_path,
-        p.user_id AS author_id,
+/*
+Columns shared by every list of posts (feed, tag pages, ...), scanned by scanFeedPosts.
+p is the listed row and d the post displayed for it, which differ only for reposts.
+Both must be visible, a repost by an account the viewer cannot see is not listed.
+$1 must always be the viewer's user ID.
+*/
+var feedPostSelect = `
+    SELECT
+        d.id AS post_id,
+        d.content,
+        d.image_path,
+        d.user_id AS author_id,
         u.name AS author_name,
         u.avatar_path AS author_avatar,
-        p.created_at,
-        COALESCE(likes_count.count, 0) AS like_count,
-        COALESCE(comments_data.comments, '[]')::json AS comments
-    FROM posts p
-    JOIN users u ON p.user_id = u.id
-    JOIN follows f ON f.followed_user_id = p.user_id
-    LEFT JOIN (
-        SELECT post_id, COUNT(*) AS count
-        FROM likes
-        GROUP BY post_id
-    ) likes_count ON likes_count.post_id = p.id
-    LEFT JOIN (
-        SELECT 
-            c.post_id,
-            JSON_AGG(JSON_BUILD_OBJECT(
+        d.created_at,
+        (SELECT COUNT(*) FROM reactions l WHERE l.post_id = d.id AND l.reaction_type = 'like') AS like_count,
+        COALESCE((
+            SELECT JSON_OBJECT_AGG(rc.reaction_type, rc.total)
+            FROM (
+                SELECT r.reaction_type, COUNT(*) AS total
+                FROM reactions r
+                WHERE r.post_id = d.id
+                GROUP BY r.reaction_type
+            ) rc
+        ), '{}'::json) AS reaction_counts,
+        (SELECT mr.reaction_type FROM reactions mr WHERE mr.post_id = d.id AND mr.user_id = $1) AS my_reaction,
+        COALESCE((
+            SELECT JSON_AGG(JSON_BUILD_OBJECT(
                 'id', c.id,
-				'post_id', c.post_id, 
+                'post_id', c.post_id,
                 'user_id', c.user_id,
-                'name', u.name,
-                'avatar', u.avatar_path,
+                'name', cu.name,
+                'avatar', cu.avatar_path,
                 'content', c.content,
-                'created_at', c.created_at
-            ) ORDER BY c.created_at ASC) AS comments
-        FROM comments c
-        JOIN users u ON c.user_id = u.id
-        WHERE c.deleted_at IS NULL
-        GROUP BY c.post_id
-    ) comments_data ON comments_data.post_id = p.id
-    WHERE f.user_id = $1
-      AND p.deleted_at IS NULL
-    ORDER BY p.created_at DESC
-    LIMIT 10
-    `
+                'created_at', c.created_at,
+                'mentions', COALESCE((
+                    SELECT JSON_AGG(JSON_BUILD_OBJECT(
+                        'user_id', m.mentioned_user_id,
+                        'username', mu.username,
+                        'start', m.start_offset,
+                        'end', m.end_offset
+                    ) ORDER BY m.start_offset)
+                    FROM mentions m
+                    JOIN users mu ON mu.id = m.mentioned_user_id
+                    WHERE m.comment_id = c.id
+                ), '[]'::json)
+            ) ORDER BY c.created_at ASC)
+            FROM comments c
+            JOIN users cu ON c.user_id = cu.id
+            WHERE c.post_id = d.id AND c.deleted_at IS NULL
+              AND NOT ` + blockedCondition("c.user_id", "$1") + `
+        ), '[]')::json AS comments,
+        COALESCE((
+            SELECT ARRAY_AGG(h.name ORDER BY h.name)
+            FROM post_hashtags ph
+            JOIN hashtags h ON h.id = ph.hashtag_id
+            WHERE ph.post_id = d.id
+        ), '{}') AS hashtags,
+        COALESCE((
+            SELECT JSON_AGG(JSON_BUILD_OBJECT(
+                'user_id', m.mentioned_user_id,
+                'username', mu.username,
+                'start', m.start_offset,
+                'end', m.end_offset
+            ) ORDER BY m.start_offset)
+            FROM mentions m
+            JOIN users mu ON mu.id = m.mentioned_user_id
+            WHERE m.post_id = d.id AND m.comment_id IS NULL
+        ), '[]')::json AS mentions,
+        d.post_type,
+        d.visibility,
+        (
+            SELECT COUNT(*) FROM posts rp
+            WHERE rp.original_post_id = d.id AND rp.post_type = 'repost' AND rp.deleted_at IS NULL
+        ) AS repost_count,
+        (
+            SELECT COUNT(*) FROM posts qp
+            WHERE qp.original_post_id = d.id AND qp.post_type = 'quote' AND qp.deleted_at IS NULL
+        ) AS quote_count,
+        CASE WHEN p.post_type = 'repost' THEN JSON_BUILD_OBJECT(
+            'id', ru.id,
+            'name', ru.name,
+            'username', ru.username,
+            'avatar_path', ru.avatar_path
+        ) END AS reposted_by,
+        CASE WHEN q.id IS NOT NULL THEN JSON_BUILD_OBJECT(
+            'id', q.id,
+            'content', q.content,
+            'image_path', q.image_path,
+            'author_id', q.user_id,
+            'author_name', qu.name,
+            'author_avatar', qu.avatar_path,
+            'created_at', q.created_at,
+            'content_warning', q.content_warning,
+            'sensitive_media', q.sensitive_media
+        ) END AS quoted_post,
+        EXISTS (SELECT 1 FROM bookmarks b WHERE b.post_id = d.id AND b.user_id = $1) AS bookmarked_by_me,
+        ` + pollSelect("d.id", "$1") + ` AS poll,
+        ` + linkPreviewSelect("d.link_url") + ` AS link_preview,
+        p.pinned_at IS NOT NULL AS is_pinned,
+        d.content_warning,
+        d.sensitive_media,
+        (d.content_warning IS NOT NULL OR d.sensitive_media) AND d.user_id <> $1
+            AND COALESCE((SELECT vs.sensitive_content FROM users vs WHERE vs.id = $1), 'blur') <> 'show' AS blurred,
+        d.thread_root_id,
+        (
+            SELECT COUNT(*) FROM posts tp
+            WHERE tp.thread_root_id = d.id AND tp.deleted_at IS NULL AND tp.status = 'published'
+              AND ` + visiblePostCondition("tp", "$1") + `
+        ) AS thread_count
+    FROM posts p
+    JOIN posts d ON d.id = CASE WHEN p.post_type = 'repost' THEN p.original_post_id ELSE p.id END
+                AND d.deleted_at IS NULL AND d.status = 'published'
+                AND ` + visiblePostCondition("d", "$1") + `
+                AND ` + visiblePostCondition("p", "$1") + `
+    JOIN users u ON d.user_id = u.id
+    JOIN users ru ON ru.id = p.user_id
+    LEFT JOIN posts q ON d.post_type = 'quote' AND q.id = d.original_post_id AND q.deleted_at IS NULL AND q.status = 'published'
+                     AND ` + visiblePostCondition("q", "$1") + `
+    LEFT JOIN users qu ON qu.id = q.user_id
+`
 
-	rows, err := r.DB.Query(ctx, query, userID)
-	if err != nil {
-		return nil, err
-	}
+/*
+SQL condition telling whether the post aliased as post may be seen by the viewer expression.
+Drafts and scheduled posts are only visible to their author, posts of private accounts only to their followers,
+and no post is visible between users who blocked one another.
+*/
+func visiblePostCondition(post, viewer string) string {
+	return fmt.Sprintf(`(
+        %[1]s.user_id = %[2]s
+        OR (%[1]s.status = 'published' AND NOT %[3]s AND (
+            NOT EXISTS (SELECT 1 FROM users vp WHERE vp.id = %[1]s.user_id AND vp.is_private)
+            OR EXISTS (SELECT 1 FROM follows vpf WHERE vpf.user_id = %[2]s AND vpf.followed_user_id = %[1]s.user_id)
+        ) AND (
+            %[1]s.visibility = 'public'
+            OR (%[1]s.visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM follows vf WHERE vf.user_id = %[2]s AND vf.followed_user_id = %[1]s.user_id
+            ))
+            OR (%[1]s.visibility = 'close_friends' AND EXISTS (
+                SELECT 1 FROM close_friends vc WHERE vc.user_id = %[1]s.user_id AND vc.friend_id = %[2]s
+            ))
+        ))
+    )`, post, viewer, blockedCondition(post+".user_id", viewer))
+}
+
+/* SQL condition telling whether either of the two user expressions blocked the other */
+func blockedCondition(userA, userB string) string {
+	return fmt.Sprintf(`EXISTS (
+        SELECT 1 FROM blocks bk
+        WHERE (bk.blocker_id = %[1]s AND bk.blocked_id = %[2]s)
+           OR (bk.blocker_id = %[2]s AND bk.blocked_id = %[1]s)
+    )`, userA, userB)
+}
+
+/*
+SQL condition leaving out posts with a content warning or sensitive media when the viewer chose to hide them.
+Only lists use it, a post opened directly is still returned, blurred.
+*/
+func sensitiveContentCondition(post, viewer string) string {
+	return fmt.Sprintf(`(
+        %[1]s.user_id = %[2]s
+        OR (%[1]s.content_warning IS NULL AND NOT %[1]s.sensitive_media)
+        OR NOT EXISTS (SELECT 1 FROM users hv WHERE hv.id = %[2]s AND hv.sensitive_content = 'hide')
+    )`, post, viewer)
+}
+
+/*
+SQL condition leaving out the posts continuing a thread whose root the viewer can see,
+the root stands for the whole thread with its thread_count.
+*/
+func threadCollapsedCondition(post, viewer string) string {
+	return fmt.Sprintf(`(
+        %[1]s.thread_root_id IS NULL
+        OR NOT EXISTS (
+            SELECT 1 FROM posts tr
+            WHERE tr.id = %[1]s.thread_root_id AND tr.deleted_at IS NULL AND tr.status = 'published'
+              AND %[2]s
+        )
+    )`, post, visiblePostCondition("tr", viewer))
+}
+
+func scanFeedPosts(rows pgx.Rows) ([]models.FeedPost, error) {
 	defer rows.Close()
 
 	var feeds []models.FeedPost
 	for rows.Next() {
 		var post models.FeedPost
-		var commentsJSON []byte
+		var reactionsJSON, commentsJSON, mentionsJSON, repostedByJSON, quotedJSON, pollJSON, previewJSON []byte
 
 		err := rows.Scan(
 			&post.ID,
@@ -81,26 +219,131 @@ func (r *FeedRepository) GetUserFeed(ctx context.Context, userID int) ([]models.
 			&post.AvatarPath,
 			&post.CreatedAt,
 			&post.LikeCount,
+			&reactionsJSON,
+			&post.MyReaction,
 			&commentsJSON,
+			&post.Hashtags,
+			&mentionsJSON,
+			&post.PostType,
+			&post.Visibility,
+			&post.RepostCount,
+			&post.QuoteCount,
+			&repostedByJSON,
+			&quotedJSON,
+			&post.BookmarkedByMe,
+			&pollJSON,
+			&previewJSON,
+			&post.IsPinned,
+			&post.ContentWarning,
+			&post.SensitiveMedia,
+			&post.Blurred,
+			&post.ThreadRootID,
+			&post.ThreadCount,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		post.CreatedAtStr = post.CreatedAt.Format("2006-01-02T15:04:05")
+		post.ContentHTML = utils.RenderMarkdown(post.Content)
+
+		post.ReactionCounts = map[string]int{}
+		if len(reactionsJSON) > 0 {
+			if err := json.Unmarshal(reactionsJSON, &post.ReactionCounts); err != nil {
+				return nil, err
+			}
+		}
 
 		if len(commentsJSON) > 0 {
 			var comments []models.Comment
 			if err := json.Unmarshal(commentsJSON, &comments); err != nil {
 				return nil, err
 			}
+			for i := range comments {
+				comments[i].ContentHTML = utils.RenderMarkdown(comments[i].Content)
+			}
 			post.Comments = comments
 		} else {
 			post.Comments = []models.Comment{}
 		}
 
+		post.Mentions = []models.Mention{}
+		if len(mentionsJSON) > 0 {
+			if err := json.Unmarshal(mentionsJSON, &post.Mentions); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(repostedByJSON) > 0 {
+			if err := json.Unmarshal(repostedByJSON, &post.RepostedBy); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(quotedJSON) > 0 {
+			if err := json.Unmarshal(quotedJSON, &post.QuotedPost); err != nil {
+				return nil, err
+			}
+			post.QuotedPost.ContentHTML = utils.RenderMarkdown(post.QuotedPost.Content)
+		}
+
+		if len(pollJSON) > 0 {
+			if err := json.Unmarshal(pollJSON, &post.Poll); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(previewJSON) > 0 {
+			if err := json.Unmarshal(previewJSON, &post.LinkPreview); err != nil {
+				return nil, err
+			}
+		}
+
 		feeds = append(feeds, post)
 	}
 
-	return feeds, nil
+	return feeds, rows.Err()
+}
+
+func (r *FeedRepository) GetUserFeed(ctx context.Context, userID int) ([]models.FeedPost, error) {
+	query := feedPostSelect + `
+    WHERE p.deleted_at IS NULL
+      AND ` + sensitiveContentCondition("d", "$1") + `
+      AND ` + threadCollapsedCondition("p", "$1") + `
+      AND (
+        p.user_id IN (SELECT f.followed_user_id FROM follows f WHERE f.user_id = $1)
+        OR p.id IN (
+            SELECT ph.post_id
+            FROM post_hashtags ph
+            JOIN hashtag_follows hf ON hf.hashtag_id = ph.hashtag_id
+            WHERE hf.user_id = $1
+        )
+      )
+    ORDER BY p.created_at DESC
+    LIMIT 10
+    `
+
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeedPosts(rows)
+}
+
+/* Profile timeline of a user, pinned posts first, then newest first */
+func (r *FeedRepository) GetUserPosts(ctx context.Context, viewerID, userID, limit, offset int) ([]models.FeedPost, error) {
+	query := feedPostSelect + `
+    WHERE p.deleted_at IS NULL AND p.user_id = $2
+      AND ` + sensitiveContentCondition("d", "$1") + `
+    ORDER BY p.pinned_at DESC NULLS LAST, p.created_at DESC, p.id DESC
+    LIMIT $3 OFFSET $4
+    `
+
+	rows, err := r.DB.Query(ctx, query, viewerID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeedPosts(rows)
 }
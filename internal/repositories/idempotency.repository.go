@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/* Idempotency keys kept in Postgres while Redis is unavailable */
+type IdempotencyRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		DB: db,
+	}
+}
+
+/* Reserve the key for a new request, or return the record of the request that already used it */
+func (r *IdempotencyRepository) Reserve(ctx context.Context, userID int, key, requestHash string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	queryExpired := "DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND expires_at <= now()"
+	if _, err := dbTx.Exec(ctx, queryExpired, userID, key); err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING
+	`
+	res, err := dbTx.Exec(ctx, query, userID, key, requestHash, ttl.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var record *models.IdempotencyRecord
+	if res.RowsAffected() == 0 {
+		record, err = scanIdempotencyRecord(dbTx.QueryRow(ctx, idempotencyRecordQuery, userID, key))
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			record = &models.IdempotencyRecord{}
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return record, nil
+}
+
+/* Record of a key that has not expired yet, nil when there is none */
+func (r *IdempotencyRepository) Find(ctx context.Context, userID int, key string) (*models.IdempotencyRecord, error) {
+	return scanIdempotencyRecord(r.DB.QueryRow(ctx, idempotencyRecordQuery, userID, key))
+}
+
+const idempotencyRecordQuery = `
+	SELECT request_hash, status_code, content_type, response_body
+	FROM idempotency_keys
+	WHERE user_id = $1 AND idempotency_key = $2 AND expires_at > now()
+`
+
+func scanIdempotencyRecord(row pgx.Row) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var statusCode *int
+	var contentType *string
+
+	err := row.Scan(&record.RequestHash, &statusCode, &contentType, &record.Body)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != nil {
+		record.StatusCode = *statusCode
+	}
+	if contentType != nil {
+		record.ContentType = *contentType
+	}
+
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID int, key string, record *models.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+	if _, err := r.DB.Exec(ctx, query, userID, key, record.StatusCode, record.ContentType, record.Body); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+/* Free a key whose request failed so it can be retried */
+func (r *IdempotencyRepository) Release(ctx context.Context, userID int, key string) error {
+	query := "DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL"
+	if _, err := r.DB.Exec(ctx, query, userID, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.DB.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/* Days of activity returned by GetInsights */
+const insightDays = 30
+
+type InsightRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewInsightRepository(db *pgxpool.Pool) *InsightRepository {
+	return &InsightRepository{
+		DB: db,
+	}
+}
+
+/*
+Add flushed views of a post on a day. Unique viewer counts come from HyperLogLogs that only grow,
+so the highest count seen is kept.
+*/
+func (r *InsightRepository) SaveImpressions(ctx context.Context, postID int, day string, views, dayViewers, totalViewers int64) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		INSERT INTO post_impressions (post_id, "day", views, unique_viewers)
+		VALUES ($1, $2::date, $3, $4)
+		ON CONFLICT (post_id, "day") DO UPDATE
+		SET views = post_impressions.views + EXCLUDED.views,
+			unique_viewers = GREATEST(post_impressions.unique_viewers, EXCLUDED.unique_viewers)
+	`
+	if _, err := dbTx.Exec(ctx, query, postID, day, views, dayViewers); err != nil {
+		/* The post is gone, its impressions with it */
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23503" {
+			return nil
+		}
+		return fmt.Errorf("failed to save impressions: %w", err)
+	}
+
+	queryTotal := "UPDATE posts SET unique_viewers = GREATEST(unique_viewers, $2) WHERE id = $1"
+	if _, err := dbTx.Exec(ctx, queryTotal, postID, totalViewers); err != nil {
+		return fmt.Errorf("failed to save unique viewers: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+/* Totals and daily activity of a post over the last days, only for its author */
+func (r *InsightRepository) GetInsights(ctx context.Context, postID, authorID int) (*models.PostInsights, error) {
+	query := `
+		SELECT
+			p.unique_viewers,
+			COALESCE((SELECT SUM(pi.views) FROM post_impressions pi WHERE pi.post_id = p.id), 0),
+			(SELECT COUNT(*) FROM reactions l WHERE l.post_id = p.id AND l.reaction_type = 'like'),
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL)
+		FROM posts p
+		WHERE p.id = $1 AND p.user_id = $2 AND p.post_type <> 'repost' AND p.deleted_at IS NULL
+	`
+
+	insights := models.PostInsights{PostID: postID}
+	err := r.DB.QueryRow(ctx, query, postID, authorID).
+		Scan(&insights.UniqueViewers, &insights.Views, &insights.LikeCount, &insights.CommentCount)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("post not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	queryTimeline := `
+		SELECT
+			TO_CHAR(d.day, 'YYYY-MM-DD'),
+			COALESCE(pi.views, 0),
+			COALESCE(pi.unique_viewers, 0),
+			(SELECT COUNT(*) FROM reactions l
+			 WHERE l.post_id = $1 AND l.reaction_type = 'like' AND l.reacted_at::date = d.day),
+			(SELECT COUNT(*) FROM comments c
+			 WHERE c.post_id = $1 AND c.deleted_at IS NULL AND c.created_at::date = d.day)
+		FROM posts p
+		CROSS JOIN LATERAL GENERATE_SERIES(
+			GREATEST(p.created_at::date, CURRENT_DATE - ($2::int - 1)),
+			CURRENT_DATE,
+			INTERVAL '1 day'
+		) AS d(day)
+		LEFT JOIN post_impressions pi ON pi.post_id = p.id AND pi."day" = d.day::date
+		WHERE p.id = $1
+		ORDER BY d.day
+	`
+	rows, err := r.DB.Query(ctx, queryTimeline, postID, insightDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	insights.Timeline = []models.InsightDay{}
+	for rows.Next() {
+		var day models.InsightDay
+		if err := rows.Scan(&day.Day, &day.Views, &day.UniqueViewers, &day.Likes, &day.Comments); err != nil {
+			return nil, err
+		}
+		insights.Timeline = append(insights.Timeline, day)
+	}
+
+	return &insights, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LinkPreviewRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewLinkPreviewRepository(db *pgxpool.Pool) *LinkPreviewRepository {
+	return &LinkPreviewRepository{
+		DB: db,
+	}
+}
+
+/* JSON of the cached preview card of the link expression, NULL when there is none */
+func linkPreviewSelect(link string) string {
+	return fmt.Sprintf(`(
+        SELECT JSON_BUILD_OBJECT(
+            'url', lp.url,
+            'title', COALESCE(lp.title, ''),
+            'description', COALESCE(lp.description, ''),
+            'image_url', COALESCE(lp.image_url, ''),
+            'site_name', COALESCE(lp.site_name, '')
+        )
+        FROM link_previews lp
+        WHERE lp.url = %s AND lp.status = 'ok'
+    )`, link)
+}
+
+/* Cached preview of a link for post responses, nil until it has been fetched */
+func getLinkPreview(ctx context.Context, dbTx pgx.Tx, link *string) (*models.LinkPreview, error) {
+	if link == nil {
+		return nil, nil
+	}
+
+	query := `
+		SELECT url, COALESCE(title, ''), COALESCE(description, ''), COALESCE(image_url, ''), COALESCE(site_name, '')
+		FROM link_previews
+		WHERE url = $1 AND status = 'ok'
+	`
+
+	var preview models.LinkPreview
+	err := dbTx.QueryRow(ctx, query, *link).
+		Scan(&preview.URL, &preview.Title, &preview.Description, &preview.ImageURL, &preview.SiteName)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &preview, nil
+}
+
+/* Whether the link was fetched, successfully or not, within maxAge */
+func (r *LinkPreviewRepository) IsFetchedWithin(ctx context.Context, link string, maxAge time.Duration) (bool, error) {
+	var fetched bool
+	query := "SELECT EXISTS (SELECT 1 FROM link_previews WHERE url = $1 AND fetched_at > now() - make_interval(secs => $2))"
+	if err := r.DB.QueryRow(ctx, query, link, maxAge.Seconds()).Scan(&fetched); err != nil {
+		return false, err
+	}
+	return fetched, nil
+}
+
+/* Cache the preview of a link, a nil preview records a failed fetch so it is not retried right away */
+func (r *LinkPreviewRepository) SaveLinkPreview(ctx context.Context, link string, preview *models.LinkPreview) error {
+	status := "failed"
+	var title, description, imageURL, siteName *string
+	if preview != nil {
+		status = "ok"
+		title, description, imageURL, siteName = &preview.Title, &preview.Description, &preview.ImageURL, &preview.SiteName
+	}
+
+	query := `
+		INSERT INTO link_previews (url, title, description, image_url, site_name, status, fetched_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, now())
+		ON CONFLICT (url) DO UPDATE
+		SET title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			image_url = EXCLUDED.image_url,
+			site_name = EXCLUDED.site_name,
+			status = EXCLUDED.status,
+			fetched_at = EXCLUDED.fetched_at
+	`
+	if _, err := r.DB.Exec(ctx, query, link, title, description, imageURL, siteName, status); err != nil {
+		return fmt.Errorf("failed to save link preview: %w", err)
+	}
+
+	return nil
+}
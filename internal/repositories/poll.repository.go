@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PollRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPollRepository(db *pgxpool.Pool) *PollRepository {
+	return &PollRepository{
+		DB: db,
+	}
+}
+
+/* JSON of the poll attached to the post expression with live counts and the viewer's votes, NULL without poll */
+func pollSelect(postID, viewer string) string {
+	return fmt.Sprintf(`(
+        SELECT JSON_BUILD_OBJECT(
+            'id', pl.id,
+            'multiple_choice', pl.multiple_choice,
+            'closes_at', TO_CHAR(pl.closes_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+            'is_closed', pl.closes_at <= now(),
+            'total_voters', (SELECT COUNT(DISTINCT pv.user_id) FROM poll_votes pv WHERE pv.poll_id = pl.id),
+            'options', (
+                SELECT JSON_AGG(JSON_BUILD_OBJECT(
+                    'id', po.id,
+                    'label', po.label,
+                    'votes', (SELECT COUNT(*) FROM poll_votes pv WHERE pv.option_id = po.id)
+                ) ORDER BY po.position)
+                FROM poll_options po
+                WHERE po.poll_id = pl.id
+            ),
+            'my_votes', COALESCE((
+                SELECT JSON_AGG(pv.option_id ORDER BY pv.option_id)
+                FROM poll_votes pv
+                WHERE pv.poll_id = pl.id AND pv.user_id = %[2]s
+            ), '[]'::json)
+        )
+        FROM polls pl
+        WHERE pl.post_id = %[1]s
+    )`, postID, viewer)
+}
+
+/* Create the poll of a new post, filling in the IDs of the poll and its options */
+func createPoll(ctx context.Context, dbTx pgx.Tx, postID int, poll *models.Poll) error {
+	query := `
+		INSERT INTO polls (post_id, multiple_choice, closes_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, closes_at
+	`
+	if err := dbTx.QueryRow(ctx, query, postID, poll.MultipleChoice, poll.ClosesAt).Scan(&poll.ID, &poll.ClosesAt); err != nil {
+		return fmt.Errorf("failed to create poll: %w", err)
+	}
+
+	for i := range poll.Options {
+		queryOption := `
+			INSERT INTO poll_options (poll_id, "position", "label")
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`
+		if err := dbTx.QueryRow(ctx, queryOption, poll.ID, i, poll.Options[i].Label).Scan(&poll.Options[i].ID); err != nil {
+			return fmt.Errorf("failed to create poll option: %w", err)
+		}
+	}
+
+	poll.ClosesAtStr = poll.ClosesAt.UTC().Format(time.RFC3339)
+	poll.MyVotes = []int{}
+
+	return nil
+}
+
+func (r *PollRepository) GetPoll(ctx context.Context, postID, viewerID int) (*models.Poll, error) {
+	var pollJSON []byte
+	if err := r.DB.QueryRow(ctx, "SELECT "+pollSelect("$1", "$2"), postID, viewerID).Scan(&pollJSON); err != nil {
+		return nil, err
+	}
+
+	var poll *models.Poll
+	if err := json.Unmarshal(pollJSON, &poll); err != nil {
+		return nil, err
+	}
+	if poll == nil {
+		return nil, fmt.Errorf("poll not found")
+	}
+
+	return poll, nil
+}
+
+/* Record the choices of a user, each user votes once per poll */
+func (r *PollRepository) Vote(ctx context.Context, postID, userID int, optionIDs []int) (*models.Poll, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	/* Locking the poll serializes the votes of a user sent concurrently */
+	query := `
+		SELECT pl.id, pl.multiple_choice, pl.closes_at <= now()
+		FROM polls pl
+		JOIN posts p ON p.id = pl.post_id
+		WHERE pl.post_id = $1 AND p.deleted_at IS NULL AND p.status = 'published'
+		  AND ` + visiblePostCondition("p", "$2") + `
+		FOR UPDATE OF pl
+	`
+
+	var pollID int
+	var multipleChoice, closed bool
+	err = dbTx.QueryRow(ctx, query, postID, userID).Scan(&pollID, &multipleChoice, &closed)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("poll not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if closed {
+		return nil, fmt.Errorf("poll is closed")
+	}
+
+	choices := make([]int, 0, len(optionIDs))
+	seen := make(map[int]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !seen[id] {
+			seen[id] = true
+			choices = append(choices, id)
+		}
+	}
+
+	if !multipleChoice && len(choices) > 1 {
+		return nil, fmt.Errorf("only one option can be chosen")
+	}
+
+	var voted bool
+	queryVoted := "SELECT EXISTS (SELECT 1 FROM poll_votes WHERE poll_id = $1 AND user_id = $2)"
+	if err := dbTx.QueryRow(ctx, queryVoted, pollID, userID).Scan(&voted); err != nil {
+		return nil, err
+	}
+	if voted {
+		return nil, fmt.Errorf("already voted")
+	}
+
+	queryVote := `
+		INSERT INTO poll_votes (poll_id, option_id, user_id)
+		SELECT $1, po.id, $2
+		FROM poll_options po
+		WHERE po.poll_id = $1 AND po.id = ANY($3)
+	`
+	res, err := dbTx.Exec(ctx, queryVote, pollID, userID, choices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert vote: %w", err)
+	}
+
+	if res.RowsAffected() != int64(len(choices)) {
+		return nil, fmt.Errorf("invalid poll option")
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetPoll(ctx, postID, userID)
+}
+
+/*
+Notify authors of polls that closed, at most limit per call. The flag is flipped in the same statement that
+inserts the notifications, and locked rows are skipped, so every poll is notified exactly once.
+*/
+func (r *PollRepository) NotifyClosedPolls(ctx context.Context, limit int) (int, error) {
+	query := `
+		WITH closed AS (
+			UPDATE polls
+			SET closed_notified = true
+			WHERE id IN (
+				SELECT pl.id
+				FROM polls pl
+				JOIN posts p ON p.id = pl.post_id
+				WHERE pl.closes_at <= now() AND NOT pl.closed_notified
+				  AND p.status = 'published' AND p.deleted_at IS NULL
+				ORDER BY pl.closes_at
+				LIMIT $1
+				FOR UPDATE OF pl SKIP LOCKED
+			) AND NOT closed_notified
+			RETURNING post_id
+		)
+		INSERT INTO notifications (receiver_id, actor_id, action_type, post_id)
+		SELECT p.user_id, p.user_id, 'poll_closed', p.id
+		FROM closed
+		JOIN posts p ON p.id = closed.post_id
+	`
+	res, err := r.DB.Exec(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to notify closed polls: %w", err)
+	}
+
+	return int(res.RowsAffected()), nil
+}
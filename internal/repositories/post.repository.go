@@ -3,8 +3,13 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,34 +23,669 @@ func NewPostRepository(db *pgxpool.Pool) *PostRepository {
 	}
 }
 
+/* Columns returned by every statement that writes a single post, scanned by scanPost */
+const postColumns = "id, content, image_path, user_id, post_type, original_post_id, visibility, status, publish_at, link_url, content_warning, sensitive_media, thread_root_id, previous_post_id, created_at"
+
+func scanPost(row pgx.Row, post *models.Post) error {
+	err := row.Scan(
+		&post.ID,
+		&post.Content,
+		&post.ImagePath,
+		&post.UserID,
+		&post.PostType,
+		&post.OriginalPostID,
+		&post.Visibility,
+		&post.Status,
+		&post.PublishAt,
+		&post.LinkURL,
+		&post.ContentWarning,
+		&post.SensitiveMedia,
+		&post.ThreadRootID,
+		&post.PreviousPostID,
+		&post.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	post.ContentHTML = utils.RenderMarkdown(post.Content)
+	return nil
+}
+
 func (r *PostRepository) CreatePost(ctx context.Context, req *models.Post) (*models.Post, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	postType := req.PostType
+	if postType == "" {
+		postType = "post"
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = "public"
+	}
+
+	status := req.Status
+	if status == "" {
+		status = "published"
+	}
+
+	/* A thread continues from one of the author's own posts and shares the root of that post */
+	var threadRootID *int
+	if req.PreviousPostID != nil {
+		queryPrevious := `
+			SELECT COALESCE(thread_root_id, id)
+			FROM posts
+			WHERE id = $1 AND user_id = $2 AND post_type <> 'repost' AND deleted_at IS NULL
+			FOR UPDATE
+		`
+		var rootID int
+		err = dbTx.QueryRow(ctx, queryPrevious, *req.PreviousPostID, req.UserID).Scan(&rootID)
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("previous post not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		threadRootID = &rootID
+	}
+
 	query := `
-		INSERT INTO posts(content, image_path, user_id)
-		VALUES ($1, $2, $3)
-		RETURNING id, content, image_path, user_id, created_at
-	`
-	values := []any{req.Content, req.ImagePath, req.UserID}
+		INSERT INTO posts(
+			content, image_path, user_id, post_type, original_post_id, visibility, status, publish_at, link_url,
+			content_warning, sensitive_media, thread_root_id, previous_post_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + postColumns
+	values := []any{
+		req.Content, req.ImagePath, req.UserID, postType, req.OriginalPostID, visibility, status, req.PublishAt,
+		utils.ExtractFirstURL(req.Content), req.ContentWarning, req.SensitiveMedia, threadRootID, req.PreviousPostID,
+	}
 
 	var post models.Post
-	err := r.DB.QueryRow(ctx, query, values...).Scan(&post.ID, &post.Content, &post.ImagePath, &post.UserID, &post.CreatedAt)
+	err = scanPost(dbTx.QueryRow(ctx, query, values...), &post)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			if pgErr.ConstraintName == "unique_thread_continuation" {
+				return nil, fmt.Errorf("thread already continued from this post")
+			}
+			return nil, fmt.Errorf("already reposted")
+		}
+		return nil, err
+	}
+
+	/* Reposts and quotes notify the author of the original post */
+	if post.OriginalPostID != nil {
+		queryNotif := `
+			INSERT INTO notifications (receiver_id, actor_id, action_type, post_id)
+			SELECT o.user_id, $1, $2, o.id
+			FROM posts o
+			WHERE o.id = $3 AND o.user_id <> $1
+		`
+		_, err = dbTx.Exec(ctx, queryNotif, post.UserID, post.PostType, *post.OriginalPostID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert notification: %w", err)
+		}
+	}
+
+	if post.ImagePath != nil {
+		if err := retainUpload(ctx, dbTx, *post.ImagePath); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Poll != nil {
+		if err := createPoll(ctx, dbTx, post.ID, req.Poll); err != nil {
+			return nil, err
+		}
+		post.Poll = req.Poll
+	}
+
+	post.Hashtags, err = syncPostHashtags(ctx, dbTx, post.ID, post.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	post.LinkPreview, err = getLinkPreview(ctx, dbTx, post.LinkURL)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Mentions, err = saveMentions(ctx, dbTx, post.ID, nil, post.UserID, post.Content)
 	if err != nil {
 		return nil, err
 	}
 
+	/* Drafts and scheduled posts notify mentioned users once they are published */
+	if post.Status == "published" {
+		if err := notifyMentions(ctx, dbTx, post.ID, post.UserID, post.Mentions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &post, nil
+}
+
+func (r *PostRepository) UpdatePost(ctx context.Context, postID, userID int, content string) (*models.Post, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE posts
+		SET content = $1, link_url = $4
+		WHERE id = $2 AND user_id = $3 AND post_type <> 'repost' AND deleted_at IS NULL
+		RETURNING ` + postColumns
+
+	var post models.Post
+	err = scanPost(dbTx.QueryRow(ctx, query, content, postID, userID, utils.ExtractFirstURL(content)), &post)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("post not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	post.Hashtags, err = syncPostHashtags(ctx, dbTx, post.ID, post.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	post.LinkPreview, err = getLinkPreview(ctx, dbTx, post.LinkURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dbTx.Exec(ctx, "DELETE FROM mentions WHERE post_id = $1 AND comment_id IS NULL", post.ID); err != nil {
+		return nil, fmt.Errorf("failed to clear mentions: %w", err)
+	}
+
+	post.Mentions, err = saveMentions(ctx, dbTx, post.ID, nil, post.UserID, post.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	/* Drafts and scheduled posts notify mentioned users once they are published */
+	if post.Status == "published" {
+		if err := notifyMentions(ctx, dbTx, post.ID, post.UserID, post.Mentions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &post, nil
 }
 
-func (r *PostRepository) GetPostOwnerID(ctx context.Context, postID int) (int, error) {
+/* Replace the hashtags of a post with the ones found in its content */
+func syncPostHashtags(ctx context.Context, dbTx pgx.Tx, postID int, content string) ([]string, error) {
+	tags := utils.ExtractHashtags(content)
+
+	if _, err := dbTx.Exec(ctx, "DELETE FROM post_hashtags WHERE post_id = $1", postID); err != nil {
+		return nil, fmt.Errorf("failed to clear hashtags: %w", err)
+	}
+
+	if len(tags) == 0 {
+		return []string{}, nil
+	}
+
+	queryTags := `
+		INSERT INTO hashtags (name)
+		SELECT UNNEST($1::text[])
+		ON CONFLICT (name) DO NOTHING
+	`
+	if _, err := dbTx.Exec(ctx, queryTags, tags); err != nil {
+		return nil, fmt.Errorf("failed to insert hashtags: %w", err)
+	}
+
+	queryPostTags := `
+		INSERT INTO post_hashtags (post_id, hashtag_id)
+		SELECT $1, id FROM hashtags WHERE name = ANY($2)
+	`
+	if _, err := dbTx.Exec(ctx, queryPostTags, postID, tags); err != nil {
+		return nil, fmt.Errorf("failed to link hashtags: %w", err)
+	}
+
+	return tags, nil
+}
+
+/* A single post rendered like in the feed, posts the viewer is not allowed to see are reported as not found */
+func (r *PostRepository) GetPost(ctx context.Context, postID, viewerID int) (*models.FeedPost, error) {
+	query := feedPostSelect + `
+    WHERE p.id = $2 AND p.deleted_at IS NULL
+    `
+
+	rows, err := r.DB.Query(ctx, query, viewerID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanFeedPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, fmt.Errorf("post not found")
+	}
+
+	return &posts[0], nil
+}
+
+/* Every post of the thread the post belongs to that the viewer can see, root first */
+func (r *PostRepository) GetThread(ctx context.Context, postID, viewerID int) ([]models.FeedPost, error) {
+	query := feedPostSelect + `
+    WHERE p.deleted_at IS NULL
+      AND p.post_type <> 'repost'
+      AND COALESCE(p.thread_root_id, p.id) = (
+        SELECT COALESCE(tp.thread_root_id, tp.id)
+        FROM posts tp
+        WHERE tp.id = $2 AND tp.post_type <> 'repost' AND tp.deleted_at IS NULL
+          AND ` + visiblePostCondition("tp", "$1") + `
+      )
+    ORDER BY p.id
+    `
+
+	rows, err := r.DB.Query(ctx, query, viewerID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := scanFeedPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, fmt.Errorf("post not found")
+	}
+
+	return posts, nil
+}
+
+/* Posts the viewer is not allowed to see are reported as not found */
+func (r *PostRepository) GetPostOwnerID(ctx context.Context, postID, viewerID int) (int, error) {
+	query := `
+		SELECT p.user_id
+		FROM posts p
+		WHERE p.id = $1 AND p.deleted_at IS NULL AND ` + visiblePostCondition("p", "$2")
+
 	var ownerID int
-	err := r.DB.QueryRow(ctx, "SELECT user_id FROM posts WHERE id=$1", postID).Scan(&ownerID)
+	err := r.DB.QueryRow(ctx, query, postID, viewerID).Scan(&ownerID)
 	if err != nil {
 		return 0, fmt.Errorf("post not found")
 	}
 	return ownerID, nil
 }
 
-/* ===================================================================================================================== LIKES */
-func (r *PostRepository) LikePost(ctx context.Context, postID, userID, postOwnerID int) (*models.Like, error) {
+/* Reposts and quotes always point at the original post, so reposting a repost targets what it reposted */
+func (r *PostRepository) GetOriginalPostID(ctx context.Context, postID, viewerID int) (int, string, error) {
+	query := `
+		SELECT o.id, o.visibility
+		FROM posts p
+		JOIN posts o ON o.id = CASE WHEN p.post_type = 'repost' THEN p.original_post_id ELSE p.id END
+		WHERE p.id = $1 AND p.deleted_at IS NULL AND o.deleted_at IS NULL AND o.status = 'published'
+		  AND ` + visiblePostCondition("o", "$2")
+
+	var originalID int
+	var visibility string
+	if err := r.DB.QueryRow(ctx, query, postID, viewerID).Scan(&originalID, &visibility); err != nil {
+		return 0, "", fmt.Errorf("post not found")
+	}
+	return originalID, visibility, nil
+}
+
+/*
+The repost is found from the post or from another repost of it without checking that the original can still be
+seen, so a repost can be removed after the original was deleted or its author blocked the reposter.
+Returns the ID of the original post.
+*/
+func (r *PostRepository) UndoRepost(ctx context.Context, postID, userID int) (int, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE posts r
+		SET deleted_at = now()
+		FROM posts p
+		WHERE p.id = $2 AND r.user_id = $1 AND r.post_type = 'repost' AND r.deleted_at IS NULL
+		  AND r.original_post_id = CASE WHEN p.post_type = 'repost' THEN p.original_post_id ELSE p.id END
+		RETURNING r.original_post_id
+	`
+	var originalPostID int
+	err = dbTx.QueryRow(ctx, query, userID, postID).Scan(&originalPostID)
+	if err == pgx.ErrNoRows {
+		return 0, fmt.Errorf("not reposted")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete repost: %w", err)
+	}
+
+	queryNotif := `
+		DELETE FROM notifications
+		WHERE actor_id = $1 AND post_id = $2 AND action_type = 'repost'
+	`
+	if _, err := dbTx.Exec(ctx, queryNotif, userID, originalPostID); err != nil {
+		return 0, fmt.Errorf("failed to delete notification: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return originalPostID, nil
+}
+
+/* ===================================================================================================================== DRAFTS */
+/* Drafts and scheduled posts of a user, the next to be published first */
+func (r *PostRepository) GetDrafts(ctx context.Context, userID int) ([]models.Post, error) {
+	query := `
+		SELECT ` + postColumns + `,
+			COALESCE((
+				SELECT ARRAY_AGG(h.name ORDER BY h.name)
+				FROM post_hashtags ph
+				JOIN hashtags h ON h.id = ph.hashtag_id
+				WHERE ph.post_id = posts.id
+			), '{}') AS hashtags
+		FROM posts
+		WHERE user_id = $1 AND status <> 'published' AND deleted_at IS NULL
+		ORDER BY publish_at ASC NULLS LAST, created_at DESC
+	`
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		err := rows.Scan(
+			&post.ID,
+			&post.Content,
+			&post.ImagePath,
+			&post.UserID,
+			&post.PostType,
+			&post.OriginalPostID,
+			&post.Visibility,
+			&post.Status,
+			&post.PublishAt,
+			&post.LinkURL,
+			&post.ContentWarning,
+			&post.SensitiveMedia,
+			&post.ThreadRootID,
+			&post.PreviousPostID,
+			&post.CreatedAt,
+			&post.Hashtags,
+		)
+		if err != nil {
+			return nil, err
+		}
+		post.ContentHTML = utils.RenderMarkdown(post.Content)
+		post.Mentions = []models.Mention{}
+		drafts = append(drafts, post)
+	}
+
+	return drafts, rows.Err()
+}
+
+/* Schedule a draft, or move it back to drafts when publishAt is nil */
+func (r *PostRepository) SchedulePost(ctx context.Context, postID, userID int, publishAt *time.Time) (*models.Post, error) {
+	query := `
+		UPDATE posts
+		SET status = CASE WHEN $3::timestamp IS NULL THEN 'draft' ELSE 'scheduled' END,
+			publish_at = $3
+		WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+		RETURNING ` + postColumns
+
+	var post models.Post
+	err := scanPost(r.DB.QueryRow(ctx, query, postID, userID, publishAt), &post)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("draft not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+/* Publish a draft or scheduled post right away */
+func (r *PostRepository) PublishPost(ctx context.Context, postID, userID int) (*models.Post, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE posts
+		SET status = 'published', publish_at = NULL, created_at = now()
+		WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+		RETURNING ` + postColumns
+
+	var post models.Post
+	err = scanPost(dbTx.QueryRow(ctx, query, postID, userID), &post)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("draft not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := notifySavedMentions(ctx, dbTx, post.ID, post.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &post, nil
+}
+
+/* The image of the draft is released, the sweeper removes it once nothing else uses it */
+func (r *PostRepository) DeleteDraft(ctx context.Context, postID, userID int) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE posts
+		SET deleted_at = now()
+		WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+		RETURNING image_path
+	`
+	var imagePath *string
+	err = dbTx.QueryRow(ctx, query, postID, userID).Scan(&imagePath)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("draft not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	if imagePath != nil {
+		if err := releaseUpload(ctx, dbTx, *imagePath); err != nil {
+			return err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Publish scheduled posts whose time has come, at most limit per call. Rows are locked with SKIP LOCKED and the
+update only matches posts still scheduled, so concurrent replicas never publish the same post twice.
+*/
+func (r *PostRepository) PublishDuePosts(ctx context.Context, limit int) (int, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE posts
+		SET status = 'published', created_at = publish_at
+		WHERE id IN (
+			SELECT id FROM posts
+			WHERE status = 'scheduled' AND publish_at <= now() AND deleted_at IS NULL
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) AND status = 'scheduled'
+		RETURNING id, user_id
+	`
+	rows, err := dbTx.Query(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	type duePost struct{ id, userID int }
+	var published []duePost
+	for rows.Next() {
+		var p duePost
+		if err := rows.Scan(&p.id, &p.userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		published = append(published, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range published {
+		if err := notifySavedMentions(ctx, dbTx, p.id, p.userID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(published), nil
+}
+
+/* ===================================================================================================================== PINS */
+const MaxPinnedPosts = 3
+
+/* Pin one of the user's own published posts to the top of their profile */
+func (r *PostRepository) PinPost(ctx context.Context, postID, userID int) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	/* Locking the user serializes concurrent pins so the limit holds */
+	if _, err := dbTx.Exec(ctx, "SELECT id FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var pinned bool
+	query := `
+		SELECT pinned_at IS NOT NULL
+		FROM posts
+		WHERE id = $1 AND user_id = $2 AND post_type <> 'repost' AND status = 'published' AND deleted_at IS NULL
+	`
+	err = dbTx.QueryRow(ctx, query, postID, userID).Scan(&pinned)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("post not found")
+	}
+	if err != nil {
+		return err
+	}
+	if pinned {
+		return fmt.Errorf("post already pinned")
+	}
+
+	var count int
+	queryCount := "SELECT COUNT(*) FROM posts WHERE user_id = $1 AND pinned_at IS NOT NULL AND deleted_at IS NULL"
+	if err := dbTx.QueryRow(ctx, queryCount, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count >= MaxPinnedPosts {
+		return fmt.Errorf("pin limit reached")
+	}
+
+	if _, err := dbTx.Exec(ctx, "UPDATE posts SET pinned_at = now() WHERE id = $1", postID); err != nil {
+		return fmt.Errorf("failed to pin post: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostRepository) UnpinPost(ctx context.Context, postID, userID int) error {
+	query := `
+		UPDATE posts
+		SET pinned_at = NULL
+		WHERE id = $1 AND user_id = $2 AND pinned_at IS NOT NULL AND deleted_at IS NULL
+	`
+	res, err := r.DB.Exec(ctx, query, postID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unpin post: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("post not pinned")
+	}
+
+	return nil
+}
+
+/* ===================================================================================================================== MEDIA */
+func (r *PostRepository) CanViewMedia(ctx context.Context, path string, viewerID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM posts p
+			WHERE p.image_path = $1
+			  AND p.deleted_at IS NULL
+			  AND ` + visiblePostCondition("p", "$2") + `
+		)
+	`
+
+	var allowed bool
+	if err := r.DB.QueryRow(ctx, query, path, viewerID).Scan(&allowed); err != nil {
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+/* ===================================================================================================================== REACTIONS */
+/*
+Set the reaction of a user on a post, replacing the previous one. Likes are reactions of type "like".
+Reacting again with the same type changes nothing, so retries are safe.
+*/
+func (r *PostRepository) React(ctx context.Context, postID, userID, postOwnerID int, reactionType string) (*models.Reaction, error) {
 	dbTx, err := r.DB.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
@@ -53,29 +693,32 @@ func (r *PostRepository) LikePost(ctx context.Context, postID, userID, postOwner
 	defer dbTx.Rollback(ctx)
 
 	query := `
-        INSERT INTO likes (post_id, user_id)
-        VALUES ($1, $2)
-        ON CONFLICT DO NOTHING
-        RETURNING id, liked_at
+        WITH previous AS (
+            SELECT reaction_type FROM reactions WHERE post_id = $1 AND user_id = $2
+        )
+        INSERT INTO reactions (post_id, user_id, reaction_type)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, post_id) DO UPDATE
+        SET reaction_type = EXCLUDED.reaction_type,
+            reacted_at = CASE WHEN reactions.reaction_type = EXCLUDED.reaction_type THEN reactions.reacted_at ELSE now() END
+        RETURNING id, reaction_type, reacted_at, (SELECT reaction_type FROM previous)
     `
 
-	var likePost models.Like
-	likePost.PostID = postID
-	likePost.UserID = userID
+	reaction := models.Reaction{
+		PostID: postID,
+		UserID: userID,
+	}
 
-	err = dbTx.QueryRow(ctx, query, postID, userID).Scan(&likePost.ID, &likePost.LikedAt)
+	var previous *string
+	err = dbTx.QueryRow(ctx, query, postID, userID, reactionType).
+		Scan(&reaction.ID, &reaction.Type, &reaction.ReactedAt, &previous)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert like: %w", err)
+		return nil, fmt.Errorf("failed to insert reaction: %w", err)
 	}
 
-	if userID != postOwnerID {
-		queryNotif := `
-            INSERT INTO notifications (receiver_id, actor_id, action_type, post_id)
-            VALUES ($1, $2, 'like', $3)
-        `
-		_, err = dbTx.Exec(ctx, queryNotif, postOwnerID, userID, postID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert notification: %w", err)
+	if userID != postOwnerID && (previous == nil || *previous != reaction.Type) {
+		if err := notifyReaction(ctx, dbTx, postOwnerID, userID, postID, reaction.Type); err != nil {
+			return nil, err
 		}
 	}
 
@@ -83,43 +726,178 @@ func (r *PostRepository) LikePost(ctx context.Context, postID, userID, postOwner
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &likePost, nil
+	return &reaction, nil
+}
+
+/* Keep at most one reaction notification per user and post, changing the reaction only updates it */
+func notifyReaction(ctx context.Context, dbTx pgx.Tx, receiverID, actorID, postID int, reactionType string) error {
+	action := "reaction"
+	if reactionType == "like" {
+		action = "like"
+	}
+
+	queryUpdate := `
+        UPDATE notifications
+        SET action_type = $3
+        WHERE actor_id = $1 AND post_id = $2 AND action_type IN ('like', 'reaction')
+    `
+	res, err := dbTx.Exec(ctx, queryUpdate, actorID, postID, action)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	if res.RowsAffected() > 0 {
+		return nil
+	}
+
+	queryInsert := `
+        INSERT INTO notifications (receiver_id, actor_id, action_type, post_id)
+        VALUES ($1, $2, $3, $4)
+    `
+	if _, err := dbTx.Exec(ctx, queryInsert, receiverID, actorID, action, postID); err != nil {
+		return fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	return nil
 }
 
-func (r *PostRepository) UnlikePost(ctx context.Context, postID, userID int) error {
+/*
+Remove the reaction of a user, only when it is of reactionType unless that is empty. Reports whether
+anything was removed. Notifications already read are kept, so toggling never notifies twice.
+*/
+func (r *PostRepository) RemoveReaction(ctx context.Context, postID, userID int, reactionType string) (bool, error) {
 	dbTx, err := r.DB.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer dbTx.Rollback(ctx)
 
-	query := ` 
-		DELETE FROM likes
-        WHERE post_id=$1 AND user_id=$2
+	query := `
+		DELETE FROM reactions
+        WHERE post_id=$1 AND user_id=$2 AND ($3 = '' OR reaction_type = $3)
 	`
-	res, err := dbTx.Exec(ctx, query, postID, userID)
+	res, err := dbTx.Exec(ctx, query, postID, userID, reactionType)
 	if err != nil {
-		return fmt.Errorf("failed to delete like: %w", err)
+		return false, fmt.Errorf("failed to delete reaction: %w", err)
 	}
 
 	if res.RowsAffected() == 0 {
-		return fmt.Errorf("like not found")
+		return false, nil
 	}
 
 	queryNotif := `
 	    DELETE FROM notifications
-        WHERE actor_id=$1 AND post_id=$2 AND action_type='like'
+        WHERE actor_id=$1 AND post_id=$2 AND action_type IN ('like', 'reaction') AND is_read = FALSE
 	`
 	_, err = dbTx.Exec(ctx, queryNotif, userID, postID)
 	if err != nil {
-		return fmt.Errorf("failed to delete notification: %w", err)
+		return false, fmt.Errorf("failed to delete notification: %w", err)
 	}
 
 	if err := dbTx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return true, nil
+}
+
+/* Whether the user likes the post, with the current like count */
+func (r *PostRepository) GetLikeState(ctx context.Context, postID, userID int) (*models.LikeState, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE reaction_type = 'like'),
+			COALESCE(BOOL_OR(user_id = $2 AND reaction_type = 'like'), FALSE)
+		FROM reactions
+		WHERE post_id = $1
+	`
+
+	state := models.LikeState{PostID: postID}
+	if err := r.DB.QueryRow(ctx, query, postID, userID).Scan(&state.LikeCount, &state.Liked); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+/* Users who reacted to a post, most recent first, optionally of a single type. Users blocked either way by the viewer are left out */
+func (r *PostRepository) GetReactions(ctx context.Context, postID, viewerID int, reactionType string, limit, offset int) ([]models.ReactionUser, error) {
+	query := `
+		SELECT u.id, u.name, u.username, u.avatar_path, r.reaction_type, r.reacted_at
+		FROM reactions r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.post_id = $1 AND ($2 = '' OR r.reaction_type = $2)
+		  AND NOT ` + blockedCondition("u.id", "$5") + `
+		ORDER BY r.reacted_at DESC, r.id DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.DB.Query(ctx, query, postID, reactionType, limit, offset, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.ReactionUser{}
+	for rows.Next() {
+		var u models.ReactionUser
+		if err := rows.Scan(&u.ID, &u.Name, &u.Username, &u.AvatarPath, &u.Type, &u.ReactedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+/* Users who liked a post, the ones the viewer follows first, then most recent first. Users blocked either way by the viewer are left out */
+func (r *PostRepository) GetLikes(ctx context.Context, postID, viewerID int, after *models.LikesCursor, limit int) ([]models.LikeUser, *models.LikesCursor, error) {
+	var followed *bool
+	var likedAt *time.Time
+	var reactionID *int
+	if after != nil {
+		followed, likedAt, reactionID = &after.Followed, &after.LikedAt, &after.ID
+	}
+
+	query := `
+		SELECT l.id, l.name, l.username, l.avatar_path, l.followed, l.reacted_at, l.reaction_id
+		FROM (
+			SELECT u.id, u.name, u.username, u.avatar_path, r.reacted_at, r.id AS reaction_id,
+				EXISTS (SELECT 1 FROM follows f WHERE f.user_id = $2 AND f.followed_user_id = u.id) AS followed
+			FROM reactions r
+			JOIN users u ON u.id = r.user_id
+			WHERE r.post_id = $1 AND r.reaction_type = 'like'
+			  AND NOT ` + blockedCondition("u.id", "$2") + `
+		) l
+		WHERE $3::bool IS NULL
+		   OR l.followed < $3
+		   OR (l.followed = $3 AND (l.reacted_at, l.reaction_id) < ($4::timestamp, $5::int4))
+		ORDER BY l.followed DESC, l.reacted_at DESC, l.reaction_id DESC
+		LIMIT $6
+	`
+	rows, err := r.DB.Query(ctx, query, postID, viewerID, followed, likedAt, reactionID, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := []models.LikeUser{}
+	for rows.Next() {
+		var u models.LikeUser
+		if err := rows.Scan(&u.ID, &u.Name, &u.Username, &u.AvatarPath, &u.FollowedByMe, &u.LikedAt, &u.ReactionID); err != nil {
+			return nil, nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *models.LikesCursor
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[limit-1]
+		next = &models.LikesCursor{Followed: last.FollowedByMe, LikedAt: last.LikedAt, ID: last.ReactionID}
+	}
+
+	return users, next, nil
 }
 
 /* ===================================================================================================================== COMMENT */
@@ -141,6 +919,7 @@ func (r *PostRepository) AddComment(ctx context.Context, comment *models.Comment
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert comment: %w", err)
 	}
+	comment.ContentHTML = utils.RenderMarkdown(comment.Content)
 
 	if comment.UserID != postOwnerID {
 		queryNotif := `
@@ -153,9 +932,137 @@ func (r *PostRepository) AddComment(ctx context.Context, comment *models.Comment
 		}
 	}
 
+	comment.Mentions, err = saveMentions(ctx, tx, comment.PostID, &comment.ID, comment.UserID, comment.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := notifyMentions(ctx, tx, comment.PostID, comment.UserID, comment.Mentions); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return comment, nil
 }
+
+/* ===================================================================================================================== MENTIONS */
+
+/*
+Store the mentions of a post (commentID nil) or comment, returning those that matched a user.
+Users blocking the author or blocked by them cannot be mentioned.
+*/
+func saveMentions(ctx context.Context, dbTx pgx.Tx, postID int, commentID *int, authorID int, content string) ([]models.Mention, error) {
+	mentions := []models.Mention{}
+
+	found := utils.ExtractMentions(content)
+	if len(found) == 0 {
+		return mentions, nil
+	}
+
+	usernames := make([]string, 0, len(found))
+	for _, m := range found {
+		usernames = append(usernames, strings.ToLower(m.Username))
+	}
+
+	queryUsers := `
+		SELECT u.id, u.username
+		FROM users u
+		WHERE lower(u.username) = ANY($1) AND u.deleted_at IS NULL
+		  AND NOT ` + blockedCondition("u.id", "$2")
+	rows, err := dbTx.Query(ctx, queryUsers, usernames, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+
+	users := map[string]models.Mention{}
+	for rows.Next() {
+		var u models.Mention
+		if err := rows.Scan(&u.UserID, &u.Username); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		users[strings.ToLower(u.Username)] = u
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO mentions (post_id, comment_id, mentioned_user_id, start_offset, end_offset)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, m := range found {
+		user, ok := users[strings.ToLower(m.Username)]
+		if !ok {
+			continue
+		}
+		m.UserID = user.UserID
+		m.Username = user.Username
+
+		if _, err := dbTx.Exec(ctx, query, postID, commentID, m.UserID, m.Start, m.End); err != nil {
+			return nil, fmt.Errorf("failed to insert mention: %w", err)
+		}
+		mentions = append(mentions, m)
+	}
+
+	return mentions, nil
+}
+
+/* Notify the users mentioned in a post when it gets published */
+func notifySavedMentions(ctx context.Context, dbTx pgx.Tx, postID, authorID int) error {
+	rows, err := dbTx.Query(ctx, "SELECT mentioned_user_id FROM mentions WHERE post_id = $1 AND comment_id IS NULL", postID)
+	if err != nil {
+		return err
+	}
+
+	var mentions []models.Mention
+	for rows.Next() {
+		var m models.Mention
+		if err := rows.Scan(&m.UserID); err != nil {
+			rows.Close()
+			return err
+		}
+		mentions = append(mentions, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return notifyMentions(ctx, dbTx, postID, authorID, mentions)
+}
+
+/* Notify mentioned users once per post, never the actor themself nor anyone who cannot see the post */
+func notifyMentions(ctx context.Context, dbTx pgx.Tx, postID, actorID int, mentions []models.Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int, 0, len(mentions))
+	for _, m := range mentions {
+		userIDs = append(userIDs, m.UserID)
+	}
+
+	query := `
+		INSERT INTO notifications (receiver_id, actor_id, action_type, post_id)
+		SELECT u.id, $2, 'mention', $1
+		FROM users u
+		JOIN posts p ON p.id = $1
+		WHERE u.id = ANY($3)
+		  AND u.id <> $2
+		  AND ` + visiblePostCondition("p", "u.id") + `
+		  AND NOT EXISTS (
+			SELECT 1 FROM notifications n
+			WHERE n.receiver_id = u.id AND n.actor_id = $2 AND n.action_type = 'mention' AND n.post_id = $1
+		  )
+	`
+	if _, err := dbTx.Exec(ctx, query, postID, actorID, userIDs); err != nil {
+		return fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TagRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewTagRepository(db *pgxpool.Pool) *TagRepository {
+	return &TagRepository{
+		DB: db,
+	}
+}
+
+func (r *TagRepository) GetPostsByTag(ctx context.Context, viewerID int, tag string, limit, offset int) ([]models.FeedPost, error) {
+	query := feedPostSelect + `
+    WHERE p.deleted_at IS NULL
+      AND ` + sensitiveContentCondition("d", "$1") + `
+      AND p.id IN (
+        SELECT ph.post_id
+        FROM post_hashtags ph
+        JOIN hashtags h ON h.id = ph.hashtag_id
+        WHERE h.name = $2
+      )
+    ORDER BY p.created_at DESC, p.id DESC
+    LIMIT $3 OFFSET $4
+    `
+
+	rows, err := r.DB.Query(ctx, query, viewerID, tag, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeedPosts(rows)
+}
+
+/* ===================================================================================================================== FOLLOWS */
+func (r *TagRepository) FollowHashtag(ctx context.Context, userID int, tag string) (*models.Hashtag, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	/* DO UPDATE instead of DO NOTHING so RETURNING also yields existing tags */
+	queryTag := `
+		INSERT INTO hashtags (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, name, created_at
+	`
+
+	var hashtag models.Hashtag
+	err = dbTx.QueryRow(ctx, queryTag, tag).Scan(&hashtag.ID, &hashtag.Name, &hashtag.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert hashtag: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, "INSERT INTO hashtag_follows (user_id, hashtag_id) VALUES ($1, $2)", userID, hashtag.ID)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("already following this hashtag")
+		}
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &hashtag, nil
+}
+
+func (r *TagRepository) UnfollowHashtag(ctx context.Context, userID int, tag string) error {
+	query := `
+		DELETE FROM hashtag_follows
+		WHERE user_id = $1
+		  AND hashtag_id = (SELECT id FROM hashtags WHERE name = $2)
+	`
+
+	res, err := r.DB.Exec(ctx, query, userID, tag)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow hashtag: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("not following this hashtag")
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UploadRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewUploadRepository(db *pgxpool.Pool) *UploadRepository {
+	return &UploadRepository{
+		DB: db,
+	}
+}
+
+func (r *UploadRepository) RegisterUpload(ctx context.Context, upload *models.Upload) error {
+	query := `
+		INSERT INTO uploads (path, hash, size)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (path) DO UPDATE SET last_seen_at = now()
+		RETURNING id, ref_count, created_at, last_seen_at
+	`
+
+	err := r.DB.QueryRow(ctx, query, upload.Path, upload.Hash, upload.Size).
+		Scan(&upload.ID, &upload.RefCount, &upload.CreatedAt, &upload.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to register upload: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UploadRepository) GetOrphanUploads(ctx context.Context, seenBefore time.Time, limit int) ([]models.Upload, error) {
+	query := `
+		SELECT id, path, ref_count, created_at, last_seen_at
+		FROM uploads
+		WHERE ref_count = 0 AND last_seen_at < $1
+		ORDER BY last_seen_at
+		LIMIT $2
+	`
+
+	rows, err := r.DB.Query(ctx, query, seenBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []models.Upload
+	for rows.Next() {
+		var u models.Upload
+		if err := rows.Scan(&u.ID, &u.Path, &u.RefCount, &u.CreatedAt, &u.LastSeenAt); err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, u)
+	}
+
+	return uploads, rows.Err()
+}
+
+/* Delete an orphan upload row and its file, unless it was referenced or re-uploaded meanwhile */
+func (r *UploadRepository) DeleteOrphanUpload(ctx context.Context, uploadID int, seenBefore time.Time, removeFile func(path string) error) (bool, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		DELETE FROM uploads
+		WHERE id = $1 AND ref_count = 0 AND last_seen_at < $2
+		RETURNING path
+	`
+
+	var path string
+	err = dbTx.QueryRow(ctx, query, uploadID, seenBefore).Scan(&path)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	if err := removeFile(path); err != nil {
+		return false, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+/* ===================================================================================================================== TUS UPLOADS */
+func (r *UploadRepository) CreateTusUpload(ctx context.Context, upload *models.TusUpload) error {
+	query := `
+		INSERT INTO tus_uploads (id, user_id, upload_length, metadata, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING upload_offset, created_at
+	`
+	values := []any{upload.ID, upload.UserID, upload.Length, upload.Metadata, upload.ExpiresAt}
+
+	err := r.DB.QueryRow(ctx, query, values...).Scan(&upload.Offset, &upload.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UploadRepository) GetTusUpload(ctx context.Context, uploadID string, userID int) (*models.TusUpload, error) {
+	query := `
+		SELECT id, user_id, upload_length, upload_offset, metadata, file_path, created_at, expires_at, completed_at
+		FROM tus_uploads
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var u models.TusUpload
+	err := r.DB.QueryRow(ctx, query, uploadID, userID).Scan(
+		&u.ID,
+		&u.UserID,
+		&u.Length,
+		&u.Offset,
+		&u.Metadata,
+		&u.FilePath,
+		&u.CreatedAt,
+		&u.ExpiresAt,
+		&u.CompletedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("upload not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+/*
+Claim the upload at the given offset before writing a chunk to it: the row stays locked while write runs and
+until the new offset is saved, so a concurrent request for the same upload is refused instead of writing over it.
+write returns how many bytes it wrote, whatever it wrote is kept even if it fails.
+*/
+func (r *UploadRepository) WriteTusChunk(ctx context.Context, uploadID string, offset int64, write func() (int64, error)) (int64, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		SELECT upload_offset
+		FROM tus_uploads
+		WHERE id = $1 AND completed_at IS NULL
+		FOR UPDATE NOWAIT
+	`
+
+	var current int64
+	err = dbTx.QueryRow(ctx, query, uploadID).Scan(&current)
+	if err == pgx.ErrNoRows {
+		return 0, fmt.Errorf("upload offset mismatch")
+	}
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "55P03" {
+			return 0, fmt.Errorf("upload is being written")
+		}
+		return 0, fmt.Errorf("failed to lock upload: %w", err)
+	}
+	if current != offset {
+		return 0, fmt.Errorf("upload offset mismatch")
+	}
+
+	written, writeErr := write()
+	if written > 0 {
+		if _, err := dbTx.Exec(ctx, "UPDATE tus_uploads SET upload_offset = $2 WHERE id = $1", uploadID, offset+written); err != nil {
+			return 0, fmt.Errorf("failed to update upload offset: %w", err)
+		}
+		if err := dbTx.Commit(ctx); err != nil {
+			return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}
+
+	return offset + written, writeErr
+}
+
+func (r *UploadRepository) CompleteTusUpload(ctx context.Context, uploadID, filePath string) error {
+	query := `
+		UPDATE tus_uploads
+		SET file_path = $2, completed_at = now()
+		WHERE id = $1
+	`
+
+	if _, err := r.DB.Exec(ctx, query, uploadID, filePath); err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	return nil
+}
+
+/* Completed, unexpired upload ready to be attached to a post */
+func (r *UploadRepository) GetCompletedTusUpload(ctx context.Context, uploadID string, userID int) (string, error) {
+	query := `
+		SELECT file_path
+		FROM tus_uploads
+		WHERE id = $1 AND user_id = $2 AND completed_at IS NOT NULL AND expires_at > now()
+	`
+
+	var filePath string
+	err := r.DB.QueryRow(ctx, query, uploadID, userID).Scan(&filePath)
+	if err == pgx.ErrNoRows {
+		return "", fmt.Errorf("upload not found or not completed")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+func (r *UploadRepository) DeleteTusUpload(ctx context.Context, uploadID string, userID int) (bool, error) {
+	res, err := r.DB.Exec(ctx, "DELETE FROM tus_uploads WHERE id = $1 AND user_id = $2", uploadID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (r *UploadRepository) DeleteExpiredTusUploads(ctx context.Context) ([]string, error) {
+	rows, err := r.DB.Query(ctx, "DELETE FROM tus_uploads WHERE expires_at < now() RETURNING id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+/* Reference counting, called inside the transaction that attaches a file */
+func retainUpload(ctx context.Context, dbTx pgx.Tx, path string) error {
+	_, err := dbTx.Exec(ctx, `UPDATE uploads SET ref_count = ref_count + 1, last_seen_at = now() WHERE path = $1`, path)
+	if err != nil {
+		return fmt.Errorf("failed to retain upload: %w", err)
+	}
+	return nil
+}
+
+/* Drop a reference, the sweeper deletes the file once nothing references it */
+func releaseUpload(ctx context.Context, dbTx pgx.Tx, path string) error {
+	_, err := dbTx.Exec(ctx, `UPDATE uploads SET ref_count = ref_count - 1, last_seen_at = now() WHERE path = $1 AND ref_count > 0`, path)
+	if err != nil {
+		return fmt.Errorf("failed to release upload: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -21,7 +22,7 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 
 func (r *UserRepository) GetAllUser(ctx context.Context) ([]models.AllUser, error) {
 	query := `
-		SELECT id, name, email, avatar_path, biography, created_at
+		SELECT id, name, username, email, avatar_path, biography, created_at
 		FROM users
 		WHERE deleted_at IS NULL
 		ORDER BY id
@@ -39,6 +40,7 @@ func (r *UserRepository) GetAllUser(ctx context.Context) ([]models.AllUser, erro
 		err := rows.Scan(
 			&u.ID,
 			&u.Name,
+			&u.Username,
 			&u.Email,
 			&u.AvatarPath,
 			&u.Biography,
@@ -53,6 +55,148 @@ func (r *UserRepository) GetAllUser(ctx context.Context) ([]models.AllUser, erro
 	return users, nil
 }
 
+/* Post count only includes the published posts the viewer can see, users blocked either way are not found */
+func (r *UserRepository) GetProfile(ctx context.Context, userID, viewerID int) (*models.UserProfile, error) {
+	query := `
+		SELECT
+			u.id, u.name, u.username, u.avatar_path, u.biography, u.created_at,
+			(SELECT COUNT(*) FROM follows f WHERE f.followed_user_id = u.id) AS follower_count,
+			(SELECT COUNT(*) FROM follows f WHERE f.user_id = u.id) AS following_count,
+			(
+				SELECT COUNT(*) FROM posts p
+				WHERE p.user_id = u.id AND p.deleted_at IS NULL AND p.status = 'published'
+				  AND ` + visiblePostCondition("p", "$2") + `
+			) AS post_count,
+			u.is_private,
+			EXISTS (SELECT 1 FROM follows f WHERE f.user_id = $2 AND f.followed_user_id = u.id) AS is_following,
+			EXISTS (SELECT 1 FROM follows f WHERE f.user_id = u.id AND f.followed_user_id = $2) AS follows_you,
+			EXISTS (SELECT 1 FROM follow_requests fr WHERE fr.requester_id = $2 AND fr.target_id = u.id) AS follow_requested
+		FROM users u
+		WHERE u.id = $1 AND u.deleted_at IS NULL
+		  AND NOT ` + blockedCondition("u.id", "$2")
+
+	var profile models.UserProfile
+	err := r.DB.QueryRow(ctx, query, userID, viewerID).Scan(
+		&profile.ID,
+		&profile.Name,
+		&profile.Username,
+		&profile.AvatarPath,
+		&profile.Biography,
+		&profile.CreatedAt,
+		&profile.FollowerCount,
+		&profile.FollowingCount,
+		&profile.PostCount,
+		&profile.IsPrivate,
+		&profile.IsFollowing,
+		&profile.FollowsYou,
+		&profile.Requested,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+/* ===================================================================================================================== PROFILE EDITING */
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, name, biography *string) error {
+	query := `
+		UPDATE users
+		SET name = COALESCE($2, name),
+			biography = CASE WHEN $3::text IS NULL THEN biography ELSE NULLIF($3, '') END
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	res, err := r.DB.Exec(ctx, query, userID, name, biography)
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+/* Point the avatar to an uploaded file, the previous one is released */
+func (r *UserRepository) SetAvatar(ctx context.Context, userID int, path string) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	var previous *string
+	err = dbTx.QueryRow(ctx, "SELECT avatar_path FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&previous)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	/* Same content, same path, nothing to count */
+	if previous != nil && *previous == path {
+		return nil
+	}
+
+	if _, err := dbTx.Exec(ctx, "UPDATE users SET avatar_path = $2 WHERE id = $1", userID, path); err != nil {
+		return fmt.Errorf("failed to update avatar: %w", err)
+	}
+
+	if err := retainUpload(ctx, dbTx, path); err != nil {
+		return err
+	}
+	if previous != nil {
+		if err := releaseUpload(ctx, dbTx, *previous); err != nil {
+			return err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) RemoveAvatar(ctx context.Context, userID int) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE users u
+		SET avatar_path = NULL
+		FROM (SELECT id, avatar_path FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE) previous
+		WHERE u.id = previous.id AND previous.avatar_path IS NOT NULL
+		RETURNING previous.avatar_path
+	`
+	var previous string
+	err = dbTx.QueryRow(ctx, query, userID).Scan(&previous)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("no avatar to remove")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove avatar: %w", err)
+	}
+
+	if err := releaseUpload(ctx, dbTx, previous); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 /* ===================================================================================================================== NOTIFICATIONS */
 func (r *UserRepository) GetNotifications(ctx context.Context, userID int) ([]models.Notifications, error) {
 	query := `
@@ -111,6 +255,7 @@ func (r *UserRepository) ReadNotification(ctx context.Context, notificationID, u
 }
 
 /* ===================================================================================================================== FOLLOWS */
+/* Follow a user, or ask to when the account is private */
 func (r *UserRepository) FollowRequest(ctx context.Context, req *models.Follows) (*models.Follows, error) {
 	dbTx, err := r.DB.Begin(ctx)
 	if err != nil {
@@ -118,6 +263,26 @@ func (r *UserRepository) FollowRequest(ctx context.Context, req *models.Follows)
 	}
 	defer dbTx.Rollback(ctx)
 
+	/* Locked so the account cannot turn public while the request is being created. Blocked users are not found */
+	var isPrivate bool
+	queryTarget := `
+		SELECT u.is_private FROM users u
+		WHERE u.id = $1 AND u.deleted_at IS NULL
+		  AND NOT ` + blockedCondition("u.id", "$2") + `
+		FOR SHARE
+	`
+	err = dbTx.QueryRow(ctx, queryTarget, req.FollowedUserID, req.UserID).Scan(&isPrivate)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if isPrivate {
+		return followPrivate(ctx, dbTx, req)
+	}
+
 	query := `
 		INSERT INTO follows(user_id, followed_user_id)
 		VALUES ($1,$2)
@@ -127,7 +292,7 @@ func (r *UserRepository) FollowRequest(ctx context.Context, req *models.Follows)
 	values := []any{req.UserID, req.FollowedUserID}
 
 	var userFollow models.Follows
-	err = dbTx.QueryRow(ctx, query, values...).Scan(&userFollow.ID, &userFollow.UserID, &userFollow.FollowedUserID, &req.CreatedAt)
+	err = dbTx.QueryRow(ctx, query, values...).Scan(&userFollow.ID, &userFollow.UserID, &userFollow.FollowedUserID, &userFollow.CreatedAt)
 	if err != nil {
 		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
 			return nil, fmt.Errorf("already following this user")
@@ -152,6 +317,48 @@ func (r *UserRepository) FollowRequest(ctx context.Context, req *models.Follows)
 	return &userFollow, nil
 }
 
+/* Store a pending request to follow a private account, the follow is created once it is approved */
+func followPrivate(ctx context.Context, dbTx pgx.Tx, req *models.Follows) (*models.Follows, error) {
+	var following bool
+	queryFollowing := "SELECT EXISTS (SELECT 1 FROM follows WHERE user_id = $1 AND followed_user_id = $2)"
+	if err := dbTx.QueryRow(ctx, queryFollowing, req.UserID, req.FollowedUserID).Scan(&following); err != nil {
+		return nil, err
+	}
+	if following {
+		return nil, fmt.Errorf("already following this user")
+	}
+
+	query := `
+		INSERT INTO follow_requests (requester_id, target_id)
+		VALUES ($1, $2)
+		RETURNING id, requester_id, target_id, created_at
+	`
+	follow := models.Follows{Pending: true}
+	err := dbTx.QueryRow(ctx, query, req.UserID, req.FollowedUserID).
+		Scan(&follow.ID, &follow.UserID, &follow.FollowedUserID, &follow.CreatedAt)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("follow already requested")
+		}
+		return nil, err
+	}
+
+	queryNotif := `
+		INSERT INTO notifications(receiver_id, actor_id, action_type)
+		VALUES ($1, $2, 'follow_request')
+	`
+	if _, err := dbTx.Exec(ctx, queryNotif, req.FollowedUserID, req.UserID); err != nil {
+		return nil, fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &follow, nil
+}
+
+/* Unfollow a user, or withdraw a pending follow request */
 func (r *UserRepository) UnfollowRequest(ctx context.Context, userID, followedUserID int) error {
 	dbTx, err := r.DB.Begin(ctx)
 	if err != nil {
@@ -168,15 +375,24 @@ func (r *UserRepository) UnfollowRequest(ctx context.Context, userID, followedUs
 		return fmt.Errorf("failed to delete like: %w", err)
 	}
 
+	actionType := "follow"
 	if res.RowsAffected() == 0 {
-		return fmt.Errorf("not following this user")
+		queryRequest := "DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2"
+		res, err = dbTx.Exec(ctx, queryRequest, userID, followedUserID)
+		if err != nil {
+			return fmt.Errorf("failed to delete follow request: %w", err)
+		}
+		if res.RowsAffected() == 0 {
+			return fmt.Errorf("not following this user")
+		}
+		actionType = "follow_request"
 	}
 
 	queryNotif := `
 		DELETE FROM notifications
-		WHERE receiver_id = $1 AND actor_id = $2 AND action_type = 'follow'
+		WHERE receiver_id = $1 AND actor_id = $2 AND action_type = $3
 	`
-	_, err = dbTx.Exec(ctx, queryNotif, followedUserID, userID)
+	_, err = dbTx.Exec(ctx, queryNotif, followedUserID, userID, actionType)
 	if err != nil {
 		return fmt.Errorf("failed to delete notification: %w", err)
 	}
@@ -187,3 +403,413 @@ func (r *UserRepository) UnfollowRequest(ctx context.Context, userID, followedUs
 
 	return nil
 }
+
+/* ===================================================================================================================== FOLLOW REQUESTS */
+/* Pending requests to follow userID, newest first */
+func (r *UserRepository) GetFollowRequests(ctx context.Context, userID int, beforeID *int, limit int) ([]models.PendingFollow, *int, error) {
+	query := `
+		SELECT fr.id, fr.created_at, u.id, u.name, u.username, u.avatar_path
+		FROM follow_requests fr
+		JOIN users u ON u.id = fr.requester_id
+		WHERE fr.target_id = $1 AND u.deleted_at IS NULL
+		  AND ($2::int4 IS NULL OR fr.id < $2)
+		ORDER BY fr.id DESC
+		LIMIT $3
+	`
+	rows, err := r.DB.Query(ctx, query, userID, beforeID, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.PendingFollow{}
+	for rows.Next() {
+		var req models.PendingFollow
+		if err := rows.Scan(&req.RequestID, &req.RequestedAt, &req.ID, &req.Name, &req.Username, &req.AvatarPath); err != nil {
+			return nil, nil, err
+		}
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var nextID *int
+	if len(requests) > limit {
+		requests = requests[:limit]
+		nextID = &requests[limit-1].RequestID
+	}
+
+	return requests, nextID, nil
+}
+
+/* Turn the request of requesterID into a follow of userID and let the requester know */
+func (r *UserRepository) ApproveFollowRequest(ctx context.Context, userID, requesterID int) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	if err := deleteFollowRequest(ctx, dbTx, userID, requesterID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO follows (user_id, followed_user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := dbTx.Exec(ctx, query, requesterID, userID); err != nil {
+		return fmt.Errorf("failed to insert follow: %w", err)
+	}
+
+	queryNotif := `
+		INSERT INTO notifications(receiver_id, actor_id, action_type)
+		VALUES ($1, $2, 'follow_accepted')
+	`
+	if _, err := dbTx.Exec(ctx, queryNotif, requesterID, userID); err != nil {
+		return fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) RejectFollowRequest(ctx context.Context, userID, requesterID int) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	if err := deleteFollowRequest(ctx, dbTx, userID, requesterID); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+/* Delete a pending request with its notification */
+func deleteFollowRequest(ctx context.Context, dbTx pgx.Tx, userID, requesterID int) error {
+	res, err := dbTx.Exec(ctx, "DELETE FROM follow_requests WHERE target_id = $1 AND requester_id = $2", userID, requesterID)
+	if err != nil {
+		return fmt.Errorf("failed to delete follow request: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("follow request not found")
+	}
+
+	queryNotif := `
+		DELETE FROM notifications
+		WHERE receiver_id = $1 AND actor_id = $2 AND action_type = 'follow_request'
+	`
+	if _, err := dbTx.Exec(ctx, queryNotif, userID, requesterID); err != nil {
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+
+	return nil
+}
+
+/* Users following userID, most recent follow first */
+func (r *UserRepository) GetFollowers(ctx context.Context, userID, viewerID int, beforeID *int, limit int) ([]models.FollowUser, *int, error) {
+	return r.listFollows(ctx, "f.followed_user_id", "f.user_id", "", userID, viewerID, beforeID, limit)
+}
+
+/* Users followed by userID, most recent follow first */
+func (r *UserRepository) GetFollowing(ctx context.Context, userID, viewerID int, beforeID *int, limit int) ([]models.FollowUser, *int, error) {
+	return r.listFollows(ctx, "f.user_id", "f.followed_user_id", "", userID, viewerID, beforeID, limit)
+}
+
+/* Followers of userID that the viewer follows too */
+func (r *UserRepository) GetMutualFollowers(ctx context.Context, userID, viewerID int, beforeID *int, limit int) ([]models.FollowUser, *int, error) {
+	mutual := "AND EXISTS (SELECT 1 FROM follows mf WHERE mf.user_id = $2 AND mf.followed_user_id = u.id)"
+	return r.listFollows(ctx, "f.followed_user_id", "f.user_id", mutual, userID, viewerID, beforeID, limit)
+}
+
+/*
+Keyset paginated list of follows where ownerColumn is userID, listing the users in listedColumn.
+Users blocked either way by the viewer are left out. Returns the follow ID to continue before when there is a next page.
+*/
+func (r *UserRepository) listFollows(ctx context.Context, ownerColumn, listedColumn, filter string, userID, viewerID int, beforeID *int, limit int) ([]models.FollowUser, *int, error) {
+	queryExists := `
+		SELECT EXISTS (
+			SELECT 1 FROM users u
+			WHERE u.id = $1 AND u.deleted_at IS NULL
+			  AND NOT ` + blockedCondition("u.id", "$2") + `
+		)
+	`
+	var exists bool
+	if err := r.DB.QueryRow(ctx, queryExists, userID, viewerID).Scan(&exists); err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, fmt.Errorf("user not found")
+	}
+
+	query := `
+		SELECT f.id, f.created_at, u.id, u.name, u.username, u.avatar_path,
+			EXISTS (SELECT 1 FROM follows vf WHERE vf.user_id = $2 AND vf.followed_user_id = u.id) AS is_following,
+			EXISTS (SELECT 1 FROM follows vb WHERE vb.user_id = u.id AND vb.followed_user_id = $2) AS follows_you
+		FROM follows f
+		JOIN users u ON u.id = ` + listedColumn + `
+		WHERE ` + ownerColumn + ` = $1 AND u.deleted_at IS NULL
+		  AND NOT ` + blockedCondition("u.id", "$2") + `
+		  AND ($3::int4 IS NULL OR f.id < $3)
+		  ` + filter + `
+		ORDER BY f.id DESC
+		LIMIT $4
+	`
+	rows, err := r.DB.Query(ctx, query, userID, viewerID, beforeID, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := []models.FollowUser{}
+	for rows.Next() {
+		var u models.FollowUser
+		err := rows.Scan(&u.FollowID, &u.FollowedAt, &u.ID, &u.Name, &u.Username, &u.AvatarPath, &u.IsFollowing, &u.FollowsYou)
+		if err != nil {
+			return nil, nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var nextID *int
+	if len(users) > limit {
+		users = users[:limit]
+		nextID = &users[limit-1].FollowID
+	}
+
+	return users, nextID, nil
+}
+
+/* ===================================================================================================================== BLOCKING */
+/* Block a user, removing the follows, follow requests, close friends and notifications between the two */
+func (r *UserRepository) Block(ctx context.Context, userID, blockedID int) error {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		INSERT INTO blocks (blocker_id, blocked_id)
+		SELECT $1::int4, u.id FROM users u
+		WHERE u.id = $2 AND u.deleted_at IS NULL
+	`
+	res, err := dbTx.Exec(ctx, query, userID, blockedID)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return fmt.Errorf("already blocked")
+		}
+		return fmt.Errorf("failed to insert block: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	cleanup := []string{
+		"DELETE FROM follows WHERE (user_id = $1 AND followed_user_id = $2) OR (user_id = $2 AND followed_user_id = $1)",
+		"DELETE FROM follow_requests WHERE (requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1)",
+		"DELETE FROM close_friends WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)",
+		"DELETE FROM notifications WHERE (receiver_id = $1 AND actor_id = $2) OR (receiver_id = $2 AND actor_id = $1)",
+	}
+	for _, q := range cleanup {
+		if _, err := dbTx.Exec(ctx, q, userID, blockedID); err != nil {
+			return fmt.Errorf("failed to remove relationship: %w", err)
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+/* Removed follows are not restored */
+func (r *UserRepository) Unblock(ctx context.Context, userID, blockedID int) error {
+	res, err := r.DB.Exec(ctx, "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2", userID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to delete block: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("not blocked")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) GetBlockedUsers(ctx context.Context, userID int) ([]models.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.username, u.avatar_path
+		FROM blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1 AND u.deleted_at IS NULL
+		ORDER BY b.id DESC
+	`
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.UserSummary{}
+	for rows.Next() {
+		var u models.UserSummary
+		if err := rows.Scan(&u.ID, &u.Name, &u.Username, &u.AvatarPath); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+/* ===================================================================================================================== CLOSE FRIENDS */
+func (r *UserRepository) GetCloseFriends(ctx context.Context, userID int) ([]models.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.username, u.avatar_path
+		FROM close_friends cf
+		JOIN users u ON u.id = cf.friend_id
+		WHERE cf.user_id = $1
+		ORDER BY cf.created_at DESC
+	`
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	friends := []models.UserSummary{}
+	for rows.Next() {
+		var u models.UserSummary
+		if err := rows.Scan(&u.ID, &u.Name, &u.Username, &u.AvatarPath); err != nil {
+			return nil, err
+		}
+		friends = append(friends, u)
+	}
+
+	return friends, rows.Err()
+}
+
+/* Users blocked either way cannot be added, they are reported as not found */
+func (r *UserRepository) AddCloseFriend(ctx context.Context, userID, friendID int) error {
+	query := `
+		INSERT INTO close_friends (user_id, friend_id)
+		SELECT $1::int4, $2::int4
+		WHERE NOT ` + blockedCondition("$1::int4", "$2::int4")
+	res, err := r.DB.Exec(ctx, query, userID, friendID)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			switch pgErr.Code {
+			case "23505":
+				return fmt.Errorf("already a close friend")
+			case "23503":
+				return fmt.Errorf("user not found")
+			}
+		}
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) RemoveCloseFriend(ctx context.Context, userID, friendID int) error {
+	res, err := r.DB.Exec(ctx, "DELETE FROM close_friends WHERE user_id = $1 AND friend_id = $2", userID, friendID)
+	if err != nil {
+		return fmt.Errorf("failed to delete close friend: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("not a close friend")
+	}
+
+	return nil
+}
+
+/* ===================================================================================================================== SETTINGS */
+func (r *UserRepository) GetSettings(ctx context.Context, userID int) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	err := r.DB.QueryRow(ctx, "SELECT sensitive_content, is_private FROM users WHERE id = $1 AND deleted_at IS NULL", userID).
+		Scan(&settings.SensitiveContent, &settings.IsPrivate)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+/* Turning a private account public approves every pending follow request */
+func (r *UserRepository) UpdateSettings(ctx context.Context, userID int, req *models.UpdateSettingsRequest) (*models.UserSettings, error) {
+	dbTx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+		UPDATE users
+		SET sensitive_content = COALESCE($2, sensitive_content),
+			is_private = COALESCE($3, is_private)
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING sensitive_content, is_private
+	`
+
+	var settings models.UserSettings
+	err = dbTx.QueryRow(ctx, query, userID, req.SensitiveContent, req.IsPrivate).Scan(&settings.SensitiveContent, &settings.IsPrivate)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.IsPrivate {
+		queryApprove := `
+			WITH approved AS (
+				DELETE FROM follow_requests
+				WHERE target_id = $1
+				RETURNING requester_id
+			), followed AS (
+				INSERT INTO follows (user_id, followed_user_id)
+				SELECT requester_id, $1 FROM approved
+				ON CONFLICT DO NOTHING
+			), cleared AS (
+				DELETE FROM notifications
+				WHERE receiver_id = $1 AND action_type = 'follow_request'
+			)
+			INSERT INTO notifications (receiver_id, actor_id, action_type)
+			SELECT requester_id, $1, 'follow_accepted' FROM approved
+		`
+		if _, err := dbTx.Exec(ctx, queryApprove, userID); err != nil {
+			return nil, fmt.Errorf("failed to approve follow requests: %w", err)
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &settings, nil
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/febryanhernanda/social-media-apps/internal/handlers"
+	"github.com/febryanhernanda/social-media-apps/internal/middlewares"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func BookmarkRouter(r *gin.Engine, bookmarkHandler *handlers.BookmarkHandler, jwtManager *utils.JWTManager, rdb *redis.Client) {
+	postRoutes := r.Group("/post")
+	postRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	postRoutes.POST(":id/bookmark", bookmarkHandler.AddBookmark)
+	postRoutes.DELETE(":id/bookmark", bookmarkHandler.RemoveBookmark)
+
+	bookmarkRoutes := r.Group("/user/me/bookmarks")
+	bookmarkRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	bookmarkRoutes.GET("", bookmarkHandler.GetBookmarks)
+	bookmarkRoutes.GET("/collections", bookmarkHandler.GetCollections)
+	bookmarkRoutes.POST("/collections", bookmarkHandler.CreateCollection)
+	bookmarkRoutes.DELETE("/collections/:id", bookmarkHandler.DeleteCollection)
+}
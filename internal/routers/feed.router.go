@@ -12,4 +12,8 @@ func FeedRouter(r *gin.Engine, feedHandler *handlers.FeedHandler, jwtManager *ut
 	feedRoutes := r.Group("/feed")
 	feedRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
 	feedRoutes.GET("/", feedHandler.GetUserFeed)
+
+	userRoutes := r.Group("/user")
+	userRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	userRoutes.GET("/:id/posts", feedHandler.GetUserPosts)
 }
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/febryanhernanda/social-media-apps/internal/handlers"
+	"github.com/febryanhernanda/social-media-apps/internal/middlewares"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func InsightRouter(r *gin.Engine, insightHandler *handlers.InsightHandler, jwtManager *utils.JWTManager, rdb *redis.Client) {
+	postRoutes := r.Group("/post")
+	postRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	postRoutes.GET(":id/insights", insightHandler.GetInsights)
+}
@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"github.com/febryanhernanda/social-media-apps/internal/handlers"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func MediaRouter(r *gin.Engine, mediaHandler *handlers.MediaHandler) {
+	r.GET("/media/*path", mediaHandler.ServeMedia)
+
+	/* Avatars are public, unlike post media */
+	r.Static("/avatar", utils.PublicDir+"/avatar")
+}
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/febryanhernanda/social-media-apps/internal/handlers"
+	"github.com/febryanhernanda/social-media-apps/internal/middlewares"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func PollRouter(r *gin.Engine, pollHandler *handlers.PollHandler, jwtManager *utils.JWTManager, rdb *redis.Client) {
+	postRoutes := r.Group("/post")
+	postRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	postRoutes.POST(":id/vote", pollHandler.Vote)
+}
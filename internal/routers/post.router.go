@@ -3,16 +3,37 @@ package routers
 import (
 	"github.com/febryanhernanda/social-media-apps/internal/handlers"
 	"github.com/febryanhernanda/social-media-apps/internal/middlewares"
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
 	"github.com/febryanhernanda/social-media-apps/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func PostRouter(r *gin.Engine, postHandler *handlers.PostHandler, jwtManager *utils.JWTManager, rdb *redis.Client) {
+func PostRouter(r *gin.Engine, postHandler *handlers.PostHandler, idempotencyRepo *repositories.IdempotencyRepository, jwtManager *utils.JWTManager, rdb *redis.Client) {
+	idempotency := middlewares.Idempotency(idempotencyRepo, rdb)
+
 	postRoutes := r.Group("/post")
 	postRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
-	postRoutes.POST("/", postHandler.CreatePost)
-	postRoutes.POST(":id/comment", postHandler.AddComment)
+	postRoutes.POST("/", idempotency, postHandler.CreatePost)
+	postRoutes.GET("drafts", postHandler.GetDrafts)
+	postRoutes.DELETE("drafts/:id", postHandler.DeleteDraft)
+	postRoutes.GET(":id", postHandler.GetPost)
+	postRoutes.PATCH(":id", postHandler.UpdatePost)
+	postRoutes.GET(":id/thread", postHandler.GetThread)
+	postRoutes.PUT(":id/schedule", postHandler.SchedulePost)
+	postRoutes.DELETE(":id/schedule", postHandler.UnschedulePost)
+	postRoutes.POST(":id/publish", postHandler.PublishPost)
+	postRoutes.POST(":id/pin", postHandler.PinPost)
+	postRoutes.DELETE(":id/pin", postHandler.UnpinPost)
+	postRoutes.POST(":id/comment", idempotency, postHandler.AddComment)
+	postRoutes.GET("reactions/types", postHandler.GetReactionTypes)
+	postRoutes.GET(":id/reactions", postHandler.GetReactions)
+	postRoutes.PUT(":id/reaction", postHandler.React)
+	postRoutes.DELETE(":id/reaction", postHandler.RemoveReaction)
+	postRoutes.GET(":id/likes", postHandler.GetLikes)
 	postRoutes.POST(":id/like", postHandler.LikePost)
 	postRoutes.DELETE(":id/unlike", postHandler.UnlikePost)
+	postRoutes.POST(":id/repost", postHandler.RepostPost)
+	postRoutes.DELETE(":id/repost", postHandler.UndoRepost)
+	postRoutes.POST(":id/quote", postHandler.QuotePost)
 }
@@ -1,13 +1,16 @@
 package routers
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/febryanhernanda/social-media-apps/docs"
 	"github.com/febryanhernanda/social-media-apps/internal/handlers"
 	"github.com/febryanhernanda/social-media-apps/internal/repositories"
 	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/febryanhernanda/social-media-apps/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
@@ -25,27 +28,69 @@ func Router(db *pgxpool.Pool, rdb *redis.Client) *gin.Engine {
 	}
 	jwtManager := utils.NewJWTManager(jwtSecret)
 
+	/* Media URL signing */
+	mediaSecret := os.Getenv("MEDIAKEY")
+	if mediaSecret == "" {
+		log.Fatal("Media Key env variable not set")
+	}
+	mediaSigner := utils.NewMediaSigner(mediaSecret, time.Hour)
+
+	/* Reactions, defaults are kept when unset */
+	utils.SetReactionTypes(os.Getenv("REACTION_TYPES"))
+
 	/* Repo & Handler */
 	authRepo := repositories.NewAuthRepository(db)
 	authHandler := handlers.NewAuthHandler(authRepo, jwtManager, rdb)
 
+	uploadRepo := repositories.NewUploadRepository(db)
+	uploadHandler := handlers.NewUploadHandler(uploadRepo)
+
+	linkPreviewRepo := repositories.NewLinkPreviewRepository(db)
+	linkPreviews := workers.NewLinkPreviewQueue(linkPreviewRepo, utils.NewLinkPreviewer(5*time.Second, 512<<10, false), rdb, 100)
+
 	postRepo := repositories.NewPostRepository(db)
-	postHandler := handlers.NewPostHandler(postRepo, rdb)
+	postHandler := handlers.NewPostHandler(postRepo, uploadRepo, mediaSigner, linkPreviews, rdb)
+	mediaHandler := handlers.NewMediaHandler(postRepo, mediaSigner)
 
 	userRepo := repositories.NewUserRepository(db)
-	userHandler := handlers.NewUserHandler(userRepo, rdb)
+	userHandler := handlers.NewUserHandler(userRepo, uploadRepo, rdb)
 
 	feedRepo := repositories.NewFeedRepository(db)
-	feedHandler := handlers.NewFeedHandler(feedRepo, rdb)
+	feedHandler := handlers.NewFeedHandler(feedRepo, mediaSigner, rdb)
+
+	bookmarkRepo := repositories.NewBookmarkRepository(db)
+	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkRepo, mediaSigner, rdb)
+
+	tagRepo := repositories.NewTagRepository(db)
+	tagHandler := handlers.NewTagHandler(tagRepo, mediaSigner, rdb)
+
+	pollRepo := repositories.NewPollRepository(db)
+	pollHandler := handlers.NewPollHandler(pollRepo, rdb)
+
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+
+	insightRepo := repositories.NewInsightRepository(db)
+	insightHandler := handlers.NewInsightHandler(insightRepo)
 
 	/* Register Router */
 	AuthRouter(r, jwtManager, rdb, authHandler)
-	PostRouter(r, postHandler, jwtManager, rdb)
+	PostRouter(r, postHandler, idempotencyRepo, jwtManager, rdb)
 	UserRouter(r, userHandler, jwtManager, rdb)
 	FeedRouter(r, feedHandler, jwtManager, rdb)
+	TagRouter(r, tagHandler, jwtManager, rdb)
+	BookmarkRouter(r, bookmarkHandler, jwtManager, rdb)
+	PollRouter(r, pollHandler, jwtManager, rdb)
+	InsightRouter(r, insightHandler, jwtManager, rdb)
+	MediaRouter(r, mediaHandler)
+	UploadRouter(r, uploadHandler, jwtManager, rdb)
 
-	/* Register file upload */
-	r.Static("/public", "./public")
+	/* Background Workers */
+	go workers.StartUploadSweeper(context.Background(), uploadRepo, time.Hour, 24*time.Hour)
+	go workers.StartPostPublisher(context.Background(), postRepo, rdb, 30*time.Second)
+	go workers.StartPollCloser(context.Background(), pollRepo, rdb, time.Minute)
+	go workers.StartImpressionFlusher(context.Background(), insightRepo, rdb, time.Minute)
+	go workers.StartIdempotencySweeper(context.Background(), idempotencyRepo, time.Hour)
+	linkPreviews.Start(context.Background(), 4)
 
 	/* Register Swagger */
 	docs.SwaggerInfo.BasePath = "/"
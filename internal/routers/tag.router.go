@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/febryanhernanda/social-media-apps/internal/handlers"
+	"github.com/febryanhernanda/social-media-apps/internal/middlewares"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TagRouter(r *gin.Engine, tagHandler *handlers.TagHandler, jwtManager *utils.JWTManager, rdb *redis.Client) {
+	tagRoutes := r.Group("/tags")
+	tagRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	tagRoutes.GET("/:tag", tagHandler.GetPostsByTag)
+	tagRoutes.POST("/:tag/follow", tagHandler.FollowHashtag)
+	tagRoutes.DELETE("/:tag/follow", tagHandler.UnfollowHashtag)
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/febryanhernanda/social-media-apps/internal/handlers"
+	"github.com/febryanhernanda/social-media-apps/internal/middlewares"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func UploadRouter(r *gin.Engine, uploadHandler *handlers.UploadHandler, jwtManager *utils.JWTManager, rdb *redis.Client) {
+	uploadRoutes := r.Group("/upload")
+	uploadRoutes.Use(middlewares.TusResumable())
+	uploadRoutes.OPTIONS("/", uploadHandler.Options)
+	uploadRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	uploadRoutes.POST("/", uploadHandler.CreateUpload)
+	uploadRoutes.HEAD("/:id", uploadHandler.GetUploadStatus)
+	uploadRoutes.PATCH("/:id", uploadHandler.PatchUpload)
+	uploadRoutes.DELETE("/:id", uploadHandler.DeleteUpload)
+}
@@ -12,8 +12,29 @@ func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler, jwtManager *ut
 	userRoutes := r.Group("/user")
 	userRoutes.GET("/", userHandler.GetAllUser)
 	userRoutes.Use(middlewares.VerifyToken(jwtManager, rdb))
+	userRoutes.GET("/:id", userHandler.GetProfile)
+	userRoutes.PATCH("/me", userHandler.UpdateProfile)
+	userRoutes.PUT("/me/avatar", userHandler.UpdateAvatar)
+	userRoutes.DELETE("/me/avatar", userHandler.RemoveAvatar)
 	userRoutes.POST("/:id/follow", userHandler.FollowRequest)
 	userRoutes.DELETE("/:id/unfollow", userHandler.UnfollowRequest)
+	userRoutes.GET("/:id/followers", userHandler.GetFollowers)
+	userRoutes.GET("/:id/followers/mutual", userHandler.GetMutualFollowers)
+	userRoutes.GET("/:id/following", userHandler.GetFollowing)
+	userRoutes.GET("/me/follow-requests", userHandler.GetFollowRequests)
+	userRoutes.POST("/me/follow-requests/:id/approve", userHandler.ApproveFollowRequest)
+	userRoutes.DELETE("/me/follow-requests/:id", userHandler.RejectFollowRequest)
+
+	userRoutes.GET("/me/close-friends", userHandler.GetCloseFriends)
+	userRoutes.POST("/me/close-friends/:id", userHandler.AddCloseFriend)
+	userRoutes.DELETE("/me/close-friends/:id", userHandler.RemoveCloseFriend)
+
+	userRoutes.POST("/:id/block", userHandler.BlockUser)
+	userRoutes.DELETE("/:id/block", userHandler.UnblockUser)
+	userRoutes.GET("/me/blocks", userHandler.GetBlockedUsers)
+
+	userRoutes.GET("/me/settings", userHandler.GetSettings)
+	userRoutes.PATCH("/me/settings", userHandler.UpdateSettings)
 
 	userRoutes.GET("/notifications", userHandler.GetNotifications)
 	userRoutes.PATCH("/notifications/:id", userHandler.ReadNotification)
@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+/*
+Times sent by clients are kept in UTC. pgx writes a time.Time into a timestamp column with its wall clock
+and drops the offset, so 09:00+07:00 would be stored as 09:00 instead of 02:00.
+*/
+func ClientTimeUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
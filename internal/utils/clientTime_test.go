@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/* Encode t as a query argument of the given column type and decode it back like a scanned column */
+func roundTrip(t *testing.T, oid uint32, value time.Time) time.Time {
+	t.Helper()
+
+	m := pgtype.NewMap()
+	encoded, err := m.Encode(oid, pgtype.BinaryFormatCode, value, nil)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	var decoded time.Time
+	if err := m.Scan(oid, pgtype.BinaryFormatCode, encoded, &decoded); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	return decoded
+}
+
+func TestClientTimeUTC(t *testing.T) {
+	sent, err := time.Parse(time.RFC3339, "2026-10-20T09:00:00+07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC)
+
+	got := ClientTimeUTC(&sent)
+	if !got.Equal(sent) || got.Location() != time.UTC || !got.Equal(want) {
+		t.Fatalf("ClientTimeUTC(%s) = %s, want %s", sent, got, want)
+	}
+	if ClientTimeUTC(nil) != nil {
+		t.Fatal("ClientTimeUTC(nil) is not nil")
+	}
+
+	/* A timestamp column keeps the wall clock, which is only right once the time is in UTC */
+	if stored := roundTrip(t, pgtype.TimestampOID, sent); stored.Equal(sent) {
+		t.Fatalf("timestamp kept the offset of %s, the normalization is no longer needed", sent)
+	}
+	if stored := roundTrip(t, pgtype.TimestampOID, *got); !stored.Equal(want) {
+		t.Fatalf("timestamp stored %s as %s, want %s", *got, stored, want)
+	}
+
+	/* timestamptz columns, used for publish_at and closes_at, keep the instant whatever the offset */
+	if stored := roundTrip(t, pgtype.TimestamptzOID, sent); !stored.Equal(want) {
+		t.Fatalf("timestamptz stored %s as %s, want %s", sent, stored, want)
+	}
+}
@@ -1,26 +1,105 @@
 package utils
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
+	"github.com/febryanhernanda/social-media-apps/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
-func UploadFile(ctx *gin.Context, formField, uploadPath, prefix, folderPath string) (string, error) {
+/* Root folder for every uploaded file */
+const PublicDir = "public"
+
+/* Record an upload before it is moved into place */
+type RegisterUploadFunc func(ctx context.Context, upload *models.Upload) error
+
+func UploadFile(ctx *gin.Context, formField, uploadPath, prefix, folderPath string, register RegisterUploadFunc) (*models.Upload, error) {
 	file, err := ctx.FormFile(formField)
 	if err != nil {
-		return "", fmt.Errorf("failed to get file from form field %s: %w", formField, err)
+		return nil, fmt.Errorf("failed to get file from form field %s: %w", formField, err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	return StoreFile(ctx, src, filepath.Ext(file.Filename), uploadPath, prefix, folderPath, register)
+}
+
+/* Store a file named after the SHA-256 of its content, so identical files are kept once */
+func StoreFile(ctx context.Context, src io.Reader, ext, uploadPath, prefix, folderPath string, register RegisterUploadFunc) (*models.Upload, error) {
+	if err := os.MkdirAll(uploadPath, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create upload folder: %w", err)
 	}
 
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%s_images_%d%s", prefix, time.Now().UnixNano(), ext)
-	fullPath := filepath.Join(uploadPath, filename)
+	tmp, err := os.CreateTemp(uploadPath, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write uploaded file: %w", err)
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	filename := fmt.Sprintf("%s_images_%s%s", prefix, hash, strings.ToLower(ext))
+	upload := &models.Upload{
+		Path: fmt.Sprintf("/%s/%s", folderPath, filename),
+		Hash: hash,
+		Size: size,
+	}
 
-	if err := ctx.SaveUploadedFile(file, fullPath); err != nil {
-		return "", err
+	/* Register first, so the sweeper never deletes a file we are about to place */
+	if register != nil {
+		if err := register(ctx, upload); err != nil {
+			return nil, fmt.Errorf("failed to register upload: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(uploadPath, filename)); err != nil {
+		return nil, fmt.Errorf("failed to move uploaded file: %w", err)
+	}
+
+	return upload, nil
+}
+
+/* Resolve a stored upload path (e.g. /post/x.jpg) to its location on disk */
+func UploadDiskPath(path string) string {
+	return filepath.Join(PublicDir, filepath.FromSlash(filepath.Clean("/"+path)))
+}
+
+/* Like UploadFile, for images that must be square, e.g. avatars. The image is cropped before it is stored */
+func UploadSquareImage(ctx *gin.Context, formField, uploadPath, prefix, folderPath string, register RegisterUploadFunc) (*models.Upload, error) {
+	file, err := ctx.FormFile(formField)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file from form field %s: %w", formField, err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	cropped, ext, err := CropSquare(src)
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Sprintf("/%s/%s", folderPath, filename), nil
+	return StoreFile(ctx, cropped, ext, uploadPath, prefix, folderPath, register)
 }
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxHashtagLength = 100
+
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#([\p{L}\p{N}_]+)`)
+
+/* Find the hashtags in post content, normalized and without duplicates */
+func ExtractHashtags(content string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tag, ok := NormalizeHashtag(match[1])
+		if !ok || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+/* Lowercase a hashtag and drop the leading #, reporting whether it is a valid tag */
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > maxHashtagLength {
+		return "", false
+	}
+
+	onlyDigits := true
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return "", false
+		}
+		if !unicode.IsDigit(r) {
+			onlyDigits = false
+		}
+	}
+
+	/* "#1" is a number, not a topic */
+	if onlyDigits {
+		return "", false
+	}
+
+	return tag, true
+}
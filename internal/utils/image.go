@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+/* Refuse images whose decoded pixels would not fit comfortably in memory */
+const maxImagePixels = 40_000_000
+
+/*
+Crop an image to the largest centered square and re-encode it, which also drops any metadata.
+GIFs keep their first frame and are stored as PNG. Returns the encoded image and its extension.
+*/
+func CropSquare(src io.Reader) (*bytes.Buffer, string, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported image, use jpeg, png or gif")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, "", fmt.Errorf("image is too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported image, use jpeg, png or gif")
+	}
+
+	bounds := img.Bounds()
+	size := min(bounds.Dx(), bounds.Dy())
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-size)/2, bounds.Min.Y+(bounds.Dy()-size)/2)
+
+	square := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(square, square.Bounds(), img, offset, draw.Src)
+
+	out := &bytes.Buffer{}
+	if format == "jpeg" {
+		err = jpeg.Encode(out, square, &jpeg.Options{Quality: 90})
+		return out, ".jpg", err
+	}
+	err = png.Encode(out, square)
+	return out, ".png", err
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	/* Set of "postID:day" buckets with impressions not flushed to Postgres yet */
+	ImpressionDirtyKey = "impressions:dirty"
+
+	/* Daily viewer sets only need to outlive the flush of their day */
+	impressionDayTTL = 72 * time.Hour
+)
+
+/* Day bucket of impressions, in UTC */
+func ImpressionDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+/* Pending view counter of a post on a day */
+func ImpressionViewsKey(postID int, day string) string {
+	return fmt.Sprintf("impressions:views:%d:%s", postID, day)
+}
+
+/* HyperLogLog of the viewers of a post on a day */
+func ImpressionDayViewersKey(postID int, day string) string {
+	return fmt.Sprintf("impressions:viewers:%d:%s", postID, day)
+}
+
+/* HyperLogLog of every viewer of a post */
+func ImpressionViewersKey(postID int) string {
+	return fmt.Sprintf("impressions:viewers:%d", postID)
+}
+
+/* Post ID and day of a dirty bucket */
+func ParseImpressionBucket(bucket string) (int, string, error) {
+	postIDStr, day, ok := strings.Cut(bucket, ":")
+	if !ok {
+		return 0, "", fmt.Errorf("invalid impression bucket %q", bucket)
+	}
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid impression bucket %q", bucket)
+	}
+	return postID, day, nil
+}
+
+/* Count an impression of every post served to the viewer, authors seeing their own posts are not counted */
+func TrackImpressions(ctx context.Context, rdb *redis.Client, viewerID int, posts []models.FeedPost) {
+	if rdb == nil {
+		return
+	}
+
+	day := ImpressionDay(time.Now())
+	pipe := rdb.Pipeline()
+	tracked := 0
+	for _, post := range posts {
+		if post.AuthorID == viewerID {
+			continue
+		}
+		tracked++
+
+		dayViewers := ImpressionDayViewersKey(post.ID, day)
+		pipe.Incr(ctx, ImpressionViewsKey(post.ID, day))
+		pipe.PFAdd(ctx, dayViewers, viewerID)
+		pipe.Expire(ctx, dayViewers, impressionDayTTL)
+		pipe.PFAdd(ctx, ImpressionViewersKey(post.ID), viewerID)
+		pipe.SAdd(ctx, ImpressionDirtyKey, fmt.Sprintf("%d:%s", post.ID, day))
+	}
+
+	if tracked == 0 {
+		return
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Println("Redis impression error: ", err)
+	}
+}
@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxLinkURLLength     = 2048
+	maxPreviewTitle      = 300
+	maxPreviewDesc       = 1000
+	maxPreviewSiteName   = 200
+	maxPreviewRedirects  = 3
+	previewUserAgent     = "social-media-apps-link-preview/1.0"
+	previewAcceptHeaders = "text/html,application/xhtml+xml"
+)
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+/* First http(s) link of post content, trailing punctuation dropped, nil when there is none */
+func ExtractFirstURL(content string) *string {
+	for _, match := range linkPattern.FindAllString(content, -1) {
+		link := strings.TrimRight(match, ".,;:!?)]}")
+		if len(link) > maxLinkURLLength {
+			continue
+		}
+
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		return &link
+	}
+	return nil
+}
+
+/* Fetches OpenGraph and Twitter card metadata of web pages */
+type LinkPreviewer struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+/*
+Every request, redirects included, must finish within timeout and at most maxBytes of the page are read.
+Connections to loopback, private, link-local and other non public addresses are refused at dial time, after
+DNS resolution, unless allowPrivate is set, which is only meant for tests against a local server.
+*/
+func NewLinkPreviewer(timeout time.Duration, maxBytes int64, allowPrivate bool) *LinkPreviewer {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to non public address %s", host)
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    timeout,
+		ResponseHeaderTimeout:  timeout,
+		MaxResponseHeaderBytes: 64 << 10,
+		DisableKeepAlives:      true,
+	}
+
+	return &LinkPreviewer{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxPreviewRedirects {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return errors.New("unsupported redirect scheme")
+				}
+				return nil
+			},
+		},
+		maxBytes: maxBytes,
+	}
+}
+
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+		"240.0.0.0/4",
+		"64:ff9b::/96",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+/* Fetch the page at rawURL and read its preview card, errors when the page has nothing to show */
+func (p *LinkPreviewer) Fetch(ctx context.Context, rawURL string) (*models.LinkPreview, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid link")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", previewUserAgent)
+	req.Header.Set("Accept", previewAcceptHeaders)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return nil, fmt.Errorf("not an html page")
+	}
+
+	preview := parsePreview(io.LimitReader(resp.Body, p.maxBytes), resp.Request.URL)
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return nil, fmt.Errorf("no preview metadata")
+	}
+	preview.URL = rawURL
+
+	return preview, nil
+}
+
+/* Read OpenGraph tags, falling back to Twitter card tags and then the page title */
+func parsePreview(body io.Reader, pageURL *url.URL) *models.LinkPreview {
+	meta := map[string]string{}
+	var title string
+
+	tokenizer := html.NewTokenizer(body)
+	inTitle := false
+
+loop:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				break loop
+			case "title":
+				inTitle = true
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var attrKey, attrValue []byte
+					attrKey, attrValue, hasAttr = tokenizer.TagAttr()
+					switch string(attrKey) {
+					case "property", "name":
+						key = strings.ToLower(strings.TrimSpace(string(attrValue)))
+					case "content":
+						content = strings.TrimSpace(string(attrValue))
+					}
+				}
+				if key != "" && content != "" {
+					if _, ok := meta[key]; !ok {
+						meta[key] = content
+					}
+				}
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if meta[key] != "" {
+				return meta[key]
+			}
+		}
+		return ""
+	}
+
+	preview := &models.LinkPreview{
+		Title:       truncateRunes(first("og:title", "twitter:title"), maxPreviewTitle),
+		Description: truncateRunes(first("og:description", "twitter:description", "description"), maxPreviewDesc),
+		SiteName:    truncateRunes(first("og:site_name", "twitter:site"), maxPreviewSiteName),
+	}
+	if preview.Title == "" {
+		preview.Title = truncateRunes(title, maxPreviewTitle)
+	}
+
+	/* Only absolute http(s) images, relative ones are resolved against the final page URL */
+	if image := first("og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		if imageURL, err := pageURL.Parse(image); err == nil &&
+			(imageURL.Scheme == "http" || imageURL.Scheme == "https") && len(imageURL.String()) <= maxLinkURLLength {
+			preview.ImageURL = imageURL.String()
+		}
+	}
+
+	return preview
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+/* Previewer allowed to reach the local test server */
+func newTestPreviewer(maxBytes int64) *LinkPreviewer {
+	return NewLinkPreviewer(2*time.Second, maxBytes, true)
+}
+
+func servePage(contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestLinkPreviewerFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", servePage("text/html; charset=utf-8", `<html><head>
+		<title>Page title</title>
+		<meta property="og:title" content="OG title">
+		<meta property="og:description" content="OG description">
+		<meta property="og:site_name" content="Example">
+		<meta property="og:image" content="https://cdn.example.com/og.png">
+		<meta name="twitter:title" content="Twitter title">
+		</head><body></body></html>`))
+	mux.HandleFunc("/twitter", servePage("text/html", `<html><head>
+		<title>Page title</title>
+		<meta name="twitter:title" content="Twitter title">
+		<meta name="twitter:description" content="Twitter description">
+		<meta name="twitter:image" content="https://cdn.example.com/tw.png">
+		</head></html>`))
+	mux.HandleFunc("/title", servePage("text/html", `<html><head><title> Only a title </title></head></html>`))
+	mux.HandleFunc("/articles/relative", servePage("text/html", `<html><head>
+		<meta property="og:title" content="Relative">
+		<meta property="og:image" content="../img/cover.png">
+		</head></html>`))
+	mux.HandleFunc("/unsafe-image", servePage("text/html", `<html><head>
+		<meta property="og:title" content="Unsafe">
+		<meta property="og:image" content="javascript:alert(1)">
+		</head></html>`))
+	mux.HandleFunc("/json", servePage("application/json", `{"title": "not html"}`))
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<html><head><title>Not found</title></head></html>`)
+	})
+	mux.HandleFunc("/empty", servePage("text/html", `<html><head></head><body><h1>No metadata</h1></body></html>`))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		title       string
+		description string
+		image       string
+		siteName    string
+		wantErr     string
+	}{
+		{
+			name:        "opengraph tags",
+			path:        "/og",
+			title:       "OG title",
+			description: "OG description",
+			image:       "https://cdn.example.com/og.png",
+			siteName:    "Example",
+		},
+		{
+			name:        "twitter card fallback",
+			path:        "/twitter",
+			title:       "Twitter title",
+			description: "Twitter description",
+			image:       "https://cdn.example.com/tw.png",
+		},
+		{
+			name:  "title fallback",
+			path:  "/title",
+			title: "Only a title",
+		},
+		{
+			name:  "relative image",
+			path:  "/articles/relative",
+			title: "Relative",
+			image: server.URL + "/img/cover.png",
+		},
+		{
+			name:  "non http image dropped",
+			path:  "/unsafe-image",
+			title: "Unsafe",
+		},
+		{name: "non html content type", path: "/json", wantErr: "not an html page"},
+		{name: "non 200 status", path: "/missing", wantErr: "unexpected status 404"},
+		{name: "no metadata", path: "/empty", wantErr: "no preview metadata"},
+	}
+
+	previewer := newTestPreviewer(64 << 10)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := server.URL + tt.path
+			preview, err := previewer.Fetch(context.Background(), link)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Fetch(%s) error = %v, want %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch(%s) error = %v", tt.path, err)
+			}
+
+			if preview.URL != link || preview.Title != tt.title || preview.Description != tt.description ||
+				preview.ImageURL != tt.image || preview.SiteName != tt.siteName {
+				t.Errorf("Fetch(%s) = %+v, want title %q, description %q, image %q, site %q",
+					tt.path, *preview, tt.title, tt.description, tt.image, tt.siteName)
+			}
+		})
+	}
+}
+
+func TestLinkPreviewerSizeLimit(t *testing.T) {
+	padding := strings.Repeat("<!-- padding -->", 1024)
+	server := httptest.NewServer(servePage("text/html",
+		`<html><head>`+padding+`<meta property="og:title" content="Too far"></head></html>`))
+	defer server.Close()
+
+	if _, err := newTestPreviewer(1<<10).Fetch(context.Background(), server.URL); err == nil || err.Error() != "no preview metadata" {
+		t.Fatalf("Fetch past the size limit error = %v, want %q", err, "no preview metadata")
+	}
+
+	preview, err := newTestPreviewer(int64(len(padding))+1<<10).Fetch(context.Background(), server.URL)
+	if err != nil || preview.Title != "Too far" {
+		t.Fatalf("Fetch within the size limit = %+v, %v, want title %q", preview, err, "Too far")
+	}
+}
+
+func TestLinkPreviewerRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		var hops int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/redirect/"), "%d", &hops)
+		if hops == 0 {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", hops-1), http.StatusFound)
+	})
+	mux.HandleFunc("/page", servePage("text/html", `<html><head><meta property="og:title" content="Landed"></head></html>`))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	previewer := newTestPreviewer(64 << 10)
+
+	/* /redirect/N takes N+1 redirects to reach the page */
+	preview, err := previewer.Fetch(context.Background(), fmt.Sprintf("%s/redirect/%d", server.URL, maxPreviewRedirects-1))
+	if err != nil || preview.Title != "Landed" {
+		t.Fatalf("Fetch with %d redirects = %+v, %v, want title %q", maxPreviewRedirects, preview, err, "Landed")
+	}
+
+	_, err = previewer.Fetch(context.Background(), fmt.Sprintf("%s/redirect/%d", server.URL, maxPreviewRedirects))
+	if err == nil || !strings.Contains(err.Error(), "too many redirects") {
+		t.Fatalf("Fetch with %d redirects error = %v, want too many redirects", maxPreviewRedirects+1, err)
+	}
+}
+
+func TestLinkPreviewerRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(servePage("text/html", `<html><head><title>Internal</title></head></html>`))
+	defer server.Close()
+
+	previewer := NewLinkPreviewer(2*time.Second, 64<<10, false)
+	for _, link := range []string{server.URL, "http://10.0.0.1/", "http://169.254.169.254/latest/meta-data/"} {
+		if _, err := previewer.Fetch(context.Background(), link); err == nil || !strings.Contains(err.Error(), "refusing to connect") {
+			t.Errorf("Fetch(%s) error = %v, want a refused connection", link, err)
+		}
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:4700::1111", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "fc00::1", want: false},
+		{ip: "fe80::1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
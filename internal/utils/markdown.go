@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/* Characters that a backslash turns back into plain text */
+const markdownEscapable = "\\`*_[]()"
+
+var bareLinkPattern = regexp.MustCompile(`^(https?://|www\.)[^\s<>"'` + "`" + `]+`)
+
+/*
+Render the markdown subset allowed in posts and comments to HTML: **bold**, *italics* or _italics_, `code`,
+[text](url) and bare links, and line breaks. Everything else is escaped, so the result only ever contains
+the tags produced here and links are limited to normalized http(s) URLs.
+*/
+func RenderMarkdown(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = renderInline(line, true)
+	}
+	return strings.Join(lines, "<br>")
+}
+
+/*
+Normalize a link written in a post: www. links get https, only http(s) links with a host are kept,
+credentials are dropped and the scheme and host are lowercased. Returns false for anything else.
+*/
+func NormalizeLink(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxLinkURLLength {
+		return "", false
+	}
+	if strings.HasPrefix(strings.ToLower(raw), "www.") {
+		raw = "https://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", false
+	}
+	parsed.User = nil
+	parsed.Host = strings.ToLower(parsed.Host)
+
+	return parsed.String(), true
+}
+
+func renderLink(href, inner string) string {
+	return `<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer" target="_blank">` + inner + `</a>`
+}
+
+/*
+Next match of a search at or after a position of s. Searches made while rendering only move forward, so the
+last answer stays right until the position passes it and every byte is scanned once: unmatched delimiters
+cannot make rendering quadratic.
+*/
+type forwardScanner struct {
+	s     string
+	index func(string) int
+	from  int
+	at    int
+}
+
+func newForwardScanner(s string, index func(string) int) *forwardScanner {
+	return &forwardScanner{s: s, index: index, from: -1}
+}
+
+/* Position of the next match at or after from, -1 when there is none */
+func (f *forwardScanner) next(from int) int {
+	if f.from >= 0 && from >= f.from && (f.at < 0 || from <= f.at) {
+		return f.at
+	}
+
+	f.from = from
+	f.at = -1
+	if from <= len(f.s) {
+		if i := f.index(f.s[from:]); i >= 0 {
+			f.at = from + i
+		}
+	}
+	return f.at
+}
+
+/* First * that is not part of a **, a single * does not close on a ** */
+func singleStar(s string) int {
+	for from := 0; ; {
+		end := strings.IndexByte(s[from:], '*')
+		if end < 0 {
+			return -1
+		}
+		end += from
+		if strings.HasPrefix(s[end:], "**") {
+			from = end + 2
+			continue
+		}
+		return end
+	}
+}
+
+/* Links are not rendered inside link text */
+func renderInline(s string, allowLinks bool) string {
+	var out, text strings.Builder
+	flush := func() {
+		out.WriteString(html.EscapeString(text.String()))
+		text.Reset()
+	}
+
+	backticks := newForwardScanner(s, func(r string) int { return strings.IndexByte(r, '`') })
+	strong := newForwardScanner(s, func(r string) int { return strings.Index(r, "**") })
+	stars := newForwardScanner(s, singleStar)
+	underscores := newForwardScanner(s, func(r string) int { return strings.IndexByte(r, '_') })
+	brackets := newForwardScanner(s, func(r string) int { return strings.IndexAny(r, "[]") })
+	parens := newForwardScanner(s, func(r string) int { return strings.IndexByte(r, ')') })
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(markdownEscapable, s[i+1]) >= 0:
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := backticks.next(i + 1); end > i+1 {
+				flush()
+				out.WriteString("<code>" + html.EscapeString(s[i+1:end]) + "</code>")
+				i = end + 1
+				continue
+			}
+
+		case c == '*' && strings.HasPrefix(s[i:], "**"):
+			if end := strong.next(i + 2); delimited(s, i+2, end) {
+				flush()
+				out.WriteString("<strong>" + renderInline(s[i+2:end], allowLinks) + "</strong>")
+				i = end + 2
+				continue
+			}
+
+		case c == '*' || (c == '_' && !wordBefore(s, i)):
+			closers := stars
+			if c == '_' {
+				closers = underscores
+			}
+			if end := closers.next(i + 1); delimited(s, i+1, end) && (c == '*' || !wordAfter(s, end+1)) {
+				flush()
+				out.WriteString("<em>" + renderInline(s[i+1:end], allowLinks) + "</em>")
+				i = end + 1
+				continue
+			}
+
+		case c == '[' && allowLinks:
+			if label, href, end, ok := markdownLink(s, i, brackets, parens); ok {
+				flush()
+				out.WriteString(renderLink(href, renderInline(label, false)))
+				i = end
+				continue
+			}
+
+		case (c == 'h' || c == 'w' || c == 'H' || c == 'W') && allowLinks && !wordBefore(s, i):
+			if match := bareLinkPattern.FindString(s[i:]); match != "" {
+				match = strings.TrimRight(match, ".,;:!?)]}")
+				if href, ok := NormalizeLink(match); ok {
+					/* Credentials dropped from the link must not show in its text either */
+					label := match
+					if strings.Contains(match, "@") {
+						label = href
+					}
+					flush()
+					out.WriteString(renderLink(href, html.EscapeString(label)))
+					i += len(match)
+					continue
+				}
+
+				/* Not a link, nor is anything else starting inside it */
+				text.WriteString(match)
+				i += len(match)
+				continue
+			}
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+
+	return out.String()
+}
+
+/* Whether s[from:end] is the text up to a closing delimiter found at end: not empty and not padded with spaces */
+func delimited(s string, from, end int) bool {
+	if end <= from {
+		return false
+	}
+	inner := s[from:end]
+	return strings.TrimSpace(inner) == inner
+}
+
+/* [label](url) starting at position i of s, with the normalized URL and the position right after it */
+func markdownLink(s string, i int, brackets, parens *forwardScanner) (string, string, int, bool) {
+	closeLabel := brackets.next(i + 1)
+	if closeLabel < i+2 || s[closeLabel] != ']' || !strings.HasPrefix(s[closeLabel+1:], "(") {
+		return "", "", 0, false
+	}
+	closeURL := parens.next(closeLabel + 2)
+	if closeURL < 0 || closeURL-(closeLabel+2) > maxLinkURLLength {
+		return "", "", 0, false
+	}
+
+	href, ok := NormalizeLink(s[closeLabel+2 : closeURL])
+	if !ok {
+		return "", "", 0, false
+	}
+	return s[i+1 : closeLabel], href, closeURL + 1, true
+}
+
+func wordBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return i > 0 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func wordAfter(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return i < len(s) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+)
+
+type MediaSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewMediaSigner(secret string, ttl time.Duration) *MediaSigner {
+	return &MediaSigner{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+/* Build a media URL for the stored path, valid for one viewer until it expires */
+func (m *MediaSigner) SignURL(path string, viewerID int) string {
+	/* Round the expiry so repeated requests get the same, browser-cacheable URL */
+	exp := time.Now().Truncate(m.ttl / 4).Add(m.ttl).Unix()
+	return fmt.Sprintf("/media%s?uid=%d&exp=%d&sig=%s", path, viewerID, exp, m.signature(path, viewerID, exp))
+}
+
+func (m *MediaSigner) SignPath(path *string, viewerID int) *string {
+	if path == nil {
+		return nil
+	}
+	signed := m.SignURL(*path, viewerID)
+	return &signed
+}
+
+/* Sign every image of a list of posts, including quoted posts */
+func (m *MediaSigner) SignFeedPosts(posts []models.FeedPost, viewerID int) {
+	for i := range posts {
+		posts[i].ImagePath = m.SignPath(posts[i].ImagePath, viewerID)
+		if posts[i].QuotedPost != nil {
+			posts[i].QuotedPost.ImagePath = m.SignPath(posts[i].QuotedPost.ImagePath, viewerID)
+		}
+	}
+}
+
+func (m *MediaSigner) Verify(path string, viewerID int, exp int64, sig string) error {
+	if time.Now().Unix() > exp {
+		return fmt.Errorf("media link expired")
+	}
+
+	expected := m.signature(path, viewerID, exp)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return fmt.Errorf("invalid media signature")
+	}
+
+	return nil
+}
+
+func (m *MediaSigner) signature(path string, viewerID int, exp int64) string {
+	mac := hmac.New(sha256.New, m.secret)
+	fmt.Fprintf(mac, "%s|%d|%d", path, viewerID, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
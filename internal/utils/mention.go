@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/febryanhernanda/social-media-apps/internal/models"
+)
+
+var (
+	mentionPattern  = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@./])@([A-Za-z0-9_]{3,30})\b`)
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+	usernameInvalid = regexp.MustCompile(`[^a-z0-9_]`)
+)
+
+/* Find @username mentions, offsets are in characters and cover the @ */
+func ExtractMentions(content string) []models.Mention {
+	var mentions []models.Mention
+
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		/* m[2]:m[3] is the username, the @ sits right before it */
+		start := utf8.RuneCountInString(content[:m[2]-1])
+		username := content[m[2]:m[3]]
+		mentions = append(mentions, models.Mention{
+			Username: username,
+			Start:    start,
+			End:      start + 1 + utf8.RuneCountInString(username),
+		})
+	}
+
+	return mentions
+}
+
+func IsValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+/* Username base for accounts registered without one, the caller appends the user id */
+func UsernameFromEmail(email string) string {
+	base := usernameInvalid.ReplaceAllString(strings.ToLower(strings.Split(email, "@")[0]), "_")
+	if len(base) > 20 {
+		base = base[:20]
+	}
+	return base
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 50
+)
+
+/* Read the page and limit query params, falling back to sane defaults */
+func GetPagination(ctx *gin.Context) (page, limit, offset int) {
+	page, err := strconv.Atoi(ctx.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit = GetLimit(ctx)
+
+	return page, limit, (page - 1) * limit
+}
+
+func GetLimit(ctx *gin.Context) int {
+	limit, err := strconv.Atoi(ctx.Query("limit"))
+	if err != nil || limit < 1 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
+
+/* Opaque cursor for keyset pagination, v is any JSON encodable position */
+func EncodeCursor(v any) string {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func DecodeCursor(cursor string, v any) error {
+	bytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(bytes, v); err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	return nil
+}
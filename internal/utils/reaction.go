@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strings"
+)
+
+/* "like" is always available, the like endpoints are aliases for it */
+var reactionTypes = []string{"like", "love", "haha", "wow", "sad", "angry"}
+
+/* Replace the available reactions with a comma separated list, keeping the defaults when empty */
+func SetReactionTypes(list string) {
+	types := []string{"like"}
+	seen := map[string]bool{"like": true}
+	for _, t := range strings.Split(list, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] || len(t) > 20 {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+
+	if len(types) > 1 {
+		reactionTypes = types
+	}
+}
+
+func ReactionTypes() []string {
+	return reactionTypes
+}
+
+func IsReactionType(t string) bool {
+	for _, reactionType := range reactionTypes {
+		if reactionType == t {
+			return true
+		}
+	}
+	return false
+}
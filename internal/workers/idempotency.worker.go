@@ -0,0 +1,30 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+)
+
+/* Periodically delete expired idempotency keys kept in Postgres, Redis expires its own */
+func StartIdempotencySweeper(ctx context.Context, repo *repositories.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := repo.DeleteExpired(ctx)
+		if err != nil {
+			log.Println("Idempotency sweeper error: ", err)
+		} else if deleted > 0 {
+			log.Printf("Idempotency sweeper deleted %d keys", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
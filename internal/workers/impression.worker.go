@@ -0,0 +1,115 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+/* Periodically move impressions counted in Redis to Postgres */
+func StartImpressionFlusher(ctx context.Context, repo *repositories.InsightRepository, rdb *redis.Client, interval time.Duration) {
+	if rdb == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushImpressions(context.Background(), repo, rdb)
+			return
+		case <-ticker.C:
+			flushImpressions(ctx, repo, rdb)
+		}
+	}
+}
+
+/*
+Every dirty bucket is taken once per pass. Buckets that fail go back to the dirty set after the pass,
+so an unavailable database is retried on the next tick instead of in a loop.
+*/
+func flushImpressions(ctx context.Context, repo *repositories.InsightRepository, rdb *redis.Client) {
+	flushed := 0
+	var failed []any
+	var lastErr error
+
+	defer func() {
+		if len(failed) > 0 {
+			if err := rdb.SAdd(ctx, utils.ImpressionDirtyKey, failed...).Err(); err != nil {
+				log.Println("Impression flusher restore error: ", err)
+			}
+			log.Printf("Impression flusher failed to save %d post days: %v", len(failed), lastErr)
+		}
+		if flushed > 0 {
+			log.Printf("Impression flusher saved %d post days", flushed)
+		}
+	}()
+
+	for {
+		/* SPOP hands every bucket to a single replica */
+		buckets, err := rdb.SPopN(ctx, utils.ImpressionDirtyKey, 500).Result()
+		if err != nil {
+			log.Println("Impression flusher error: ", err)
+			return
+		}
+		if len(buckets) == 0 {
+			return
+		}
+
+		for _, bucket := range buckets {
+			postID, day, err := utils.ParseImpressionBucket(bucket)
+			if err != nil {
+				log.Println("Impression flusher error: ", err)
+				continue
+			}
+
+			if err := flushImpressionBucket(ctx, repo, rdb, postID, day); err != nil {
+				failed = append(failed, bucket)
+				lastErr = err
+				continue
+			}
+			flushed++
+		}
+	}
+}
+
+func flushImpressionBucket(ctx context.Context, repo *repositories.InsightRepository, rdb *redis.Client, postID int, day string) error {
+	/* GETDEL takes the pending views atomically, views counted meanwhile wait for the next flush */
+	viewsKey := utils.ImpressionViewsKey(postID, day)
+	views, err := rdb.GetDel(ctx, viewsKey).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	dayViewers, err := rdb.PFCount(ctx, utils.ImpressionDayViewersKey(postID, day)).Result()
+	if err != nil {
+		return restoreImpressions(ctx, rdb, viewsKey, views, err)
+	}
+
+	totalViewers, err := rdb.PFCount(ctx, utils.ImpressionViewersKey(postID)).Result()
+	if err != nil {
+		return restoreImpressions(ctx, rdb, viewsKey, views, err)
+	}
+
+	if err := repo.SaveImpressions(ctx, postID, day, views, dayViewers, totalViewers); err != nil {
+		return restoreImpressions(ctx, rdb, viewsKey, views, err)
+	}
+
+	return nil
+}
+
+/* Put taken views back so a failed flush does not lose them, the caller puts the bucket back */
+func restoreImpressions(ctx context.Context, rdb *redis.Client, viewsKey string, views int64, cause error) error {
+	if views > 0 {
+		if err := rdb.IncrBy(ctx, viewsKey, views).Err(); err != nil {
+			log.Println("Impression flusher restore error: ", err)
+		}
+	}
+	return cause
+}
@@ -0,0 +1,83 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+/* Links are fetched again at most once per refresh period, failed ones included */
+const linkPreviewRefresh = 24 * time.Hour
+
+/* Fetches link previews of new posts in the background with a fixed number of workers */
+type LinkPreviewQueue struct {
+	repo      *repositories.LinkPreviewRepository
+	previewer *utils.LinkPreviewer
+	rdb       *redis.Client
+	links     chan string
+}
+
+func NewLinkPreviewQueue(repo *repositories.LinkPreviewRepository, previewer *utils.LinkPreviewer, rdb *redis.Client, size int) *LinkPreviewQueue {
+	return &LinkPreviewQueue{
+		repo:      repo,
+		previewer: previewer,
+		rdb:       rdb,
+		links:     make(chan string, size),
+	}
+}
+
+/* Queue a link without blocking, previews are best effort so links are dropped when the queue is full */
+func (q *LinkPreviewQueue) Enqueue(link string) {
+	select {
+	case q.links <- link:
+	default:
+		log.Println("Link preview queue full, dropping ", link)
+	}
+}
+
+func (q *LinkPreviewQueue) Start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case link := <-q.links:
+					q.fetch(ctx, link)
+				}
+			}
+		}()
+	}
+}
+
+func (q *LinkPreviewQueue) fetch(ctx context.Context, link string) {
+	fetched, err := q.repo.IsFetchedWithin(ctx, link, linkPreviewRefresh)
+	if err != nil {
+		log.Println("Link preview error: ", err)
+		return
+	}
+	if fetched {
+		return
+	}
+
+	preview, err := q.previewer.Fetch(ctx, link)
+	if err != nil {
+		log.Printf("Link preview of %s failed: %s", link, err)
+	}
+
+	if err := q.repo.SaveLinkPreview(ctx, link, preview); err != nil {
+		log.Println("Link preview error: ", err)
+		return
+	}
+
+	/* Cached feeds were built without the card */
+	if preview != nil {
+		if err := utils.InvalidateCache(ctx, q.rdb, []string{"feed:post"}); err != nil {
+			log.Println("Redis delete cache error:", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+/* Periodically notify authors whose polls have closed */
+func StartPollCloser(ctx context.Context, repo *repositories.PollRepository, rdb *redis.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		closePolls(ctx, repo, rdb)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func closePolls(ctx context.Context, repo *repositories.PollRepository, rdb *redis.Client) {
+	total := 0
+	for {
+		closed, err := repo.NotifyClosedPolls(ctx, 100)
+		if err != nil {
+			log.Println("Poll closer error: ", err)
+			break
+		}
+		total += closed
+		if closed < 100 {
+			break
+		}
+	}
+
+	if total == 0 {
+		return
+	}
+
+	log.Printf("Poll closer notified %d closed polls", total)
+
+	/* Cached feeds still show these polls as open */
+	if err := utils.InvalidateCache(ctx, rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+}
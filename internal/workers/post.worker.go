@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+/* Periodically publish scheduled posts whose time has come */
+func StartPostPublisher(ctx context.Context, repo *repositories.PostRepository, rdb *redis.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		publishDuePosts(ctx, repo, rdb)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func publishDuePosts(ctx context.Context, repo *repositories.PostRepository, rdb *redis.Client) {
+	total := 0
+	for {
+		published, err := repo.PublishDuePosts(ctx, 100)
+		if err != nil {
+			log.Println("Post publisher error: ", err)
+			break
+		}
+		total += published
+		if published < 100 {
+			break
+		}
+	}
+
+	if total == 0 {
+		return
+	}
+
+	log.Printf("Post publisher published %d scheduled posts", total)
+
+	if err := utils.InvalidateCache(ctx, rdb, []string{"feed:post"}); err != nil {
+		log.Println("Redis delete cache error:", err)
+	}
+}
@@ -0,0 +1,79 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/febryanhernanda/social-media-apps/internal/repositories"
+	"github.com/febryanhernanda/social-media-apps/internal/utils"
+)
+
+/* Periodically delete expired resumable uploads and files that no post references anymore */
+func StartUploadSweeper(ctx context.Context, repo *repositories.UploadRepository, interval, threshold time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sweepExpiredTusUploads(ctx, repo)
+		sweepOrphanUploads(ctx, repo, threshold)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sweepOrphanUploads(ctx context.Context, repo *repositories.UploadRepository, threshold time.Duration) {
+	seenBefore := time.Now().Add(-threshold)
+
+	orphans, err := repo.GetOrphanUploads(ctx, seenBefore, 100)
+	if err != nil {
+		log.Println("Upload sweeper error: ", err)
+		return
+	}
+
+	removed := 0
+	for _, orphan := range orphans {
+		deleted, err := repo.DeleteOrphanUpload(ctx, orphan.ID, seenBefore, removeUploadFile)
+		if err != nil {
+			log.Println("Upload sweeper delete error: ", err)
+			continue
+		}
+		if deleted {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("Upload sweeper removed %d orphan files", removed)
+	}
+}
+
+func sweepExpiredTusUploads(ctx context.Context, repo *repositories.UploadRepository) {
+	ids, err := repo.DeleteExpiredTusUploads(ctx)
+	if err != nil {
+		log.Println("Upload sweeper error: ", err)
+		return
+	}
+
+	for _, id := range ids {
+		err := os.Remove(utils.TusFilePath(id))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Println("Upload sweeper delete error: ", err)
+		}
+	}
+}
+
+func removeUploadFile(path string) error {
+	err := os.Remove(utils.UploadDiskPath(path))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}